@@ -1,7 +1,9 @@
 package api
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -11,6 +13,8 @@ import (
 
 	"github.com/grafana/grafana/pkg/infra/db/dbtest"
 	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/services/updatechecker"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/web"
 )
@@ -53,6 +57,78 @@ func TestHealthAPI_AnonymousHideVersion(t *testing.T) {
 	require.JSONEq(t, expectedBody, rec.Body.String())
 }
 
+func TestHealthAPI_UpdateStatus(t *testing.T) {
+	for _, expose := range []bool{false, true} {
+		t.Run(fmt.Sprintf("expose_in_health=%t", expose), func(t *testing.T) {
+			m, hs := setupHealthAPITestEnvironment(t, func(cfg *setting.Cfg) {
+				cfg.BuildVersion = "9.3.0"
+				cfg.BuildCommit = "59906ab1bf"
+				cfg.CheckForGrafanaUpdates = true
+				cfg.ExposeUpdateStatusInHealth = expose
+			})
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"stable": "9.3.1"}`))
+			}))
+			t.Cleanup(server.Close)
+			hs.grafanaUpdateChecker = updatechecker.New(hs.Cfg, tracing.InitializeTracerForTest(), updatechecker.WithURLs([]string{server.URL}))
+			require.NoError(t, hs.grafanaUpdateChecker.CheckNow(context.Background()))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/health", nil)
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, req)
+
+			require.Equal(t, 200, rec.Code)
+			expectedBody := `
+				{
+					"database": "ok",
+					"version": "9.3.0",
+					"commit": "59906ab1bf"
+				}
+			`
+			if expose {
+				expectedBody = `
+					{
+						"database": "ok",
+						"version": "9.3.0",
+						"commit": "59906ab1bf",
+						"update": {
+							"version": "9.3.0",
+							"latest": "9.3.1",
+							"hasUpdate": true
+						}
+					}
+				`
+			}
+			require.JSONEq(t, expectedBody, rec.Body.String())
+		})
+	}
+
+	t.Run("anonymous_hide_version", func(t *testing.T) {
+		m, hs := setupHealthAPITestEnvironment(t, func(cfg *setting.Cfg) {
+			cfg.BuildVersion = "9.3.0"
+			cfg.BuildCommit = "59906ab1bf"
+			cfg.CheckForGrafanaUpdates = true
+			cfg.ExposeUpdateStatusInHealth = true
+			cfg.AnonymousHideVersion = true
+		})
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"stable": "9.3.1"}`))
+		}))
+		t.Cleanup(server.Close)
+		hs.grafanaUpdateChecker = updatechecker.New(hs.Cfg, tracing.InitializeTracerForTest(), updatechecker.WithURLs([]string{server.URL}))
+		require.NoError(t, hs.grafanaUpdateChecker.CheckNow(context.Background()))
+
+		req := httptest.NewRequest(http.MethodGet, "/api/health", nil)
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, req)
+
+		require.Equal(t, 200, rec.Code)
+		require.JSONEq(t, `{"database": "ok", "update": {"hasUpdate": true}}`, rec.Body.String())
+	})
+}
+
 func TestHealthAPI_DatabaseHealthy(t *testing.T) {
 	const cacheKey = "db-healthy"
 
@@ -690,6 +690,16 @@ func (hs *HTTPServer) apiHealthHandler(ctx *web.Context) {
 		data.Set("version", hs.Cfg.BuildVersion)
 		data.Set("commit", hs.Cfg.BuildCommit)
 	}
+	if hs.Cfg.ExposeUpdateStatusInHealth && hs.grafanaUpdateChecker != nil {
+		status := hs.grafanaUpdateChecker.Status()
+		update := map[string]interface{}{"hasUpdate": status.UpdateAvailable}
+		// The versions would give away the hidden build version.
+		if !hs.Cfg.AnonymousHideVersion {
+			update["version"] = status.CurrentVersion
+			update["latest"] = status.LatestVersion
+		}
+		data.Set("update", update)
+	}
 
 	if !hs.databaseHealthy(ctx.Req.Context()) {
 		data.Set("database", "failing")
@@ -173,6 +173,7 @@ var wireSet = wire.NewSet(
 	kvstore.ProvideService,
 	updatechecker.ProvideGrafanaService,
 	updatechecker.ProvidePluginsService,
+	updatechecker.ProvideUpdateCheckers,
 	uss.ProvideService,
 	pluginsintegration.WireSet,
 	pluginDashboards.ProvideFileStoreManager,
@@ -220,6 +221,7 @@ var wireSet = wire.NewSet(
 	notifications.ProvideService,
 	notifications.ProvideSmtpService,
 	metrics.ProvideService,
+	metrics.ProvideRegisterer,
 	testdatasource.ProvideService,
 	social.ProvideService,
 	influxdb.ProvideService,
@@ -31,6 +31,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/searchusers/filters"
 	"github.com/grafana/grafana/pkg/services/sqlstore/migrations"
 	"github.com/grafana/grafana/pkg/services/thumbs"
+	"github.com/grafana/grafana/pkg/services/updatechecker"
 	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/services/validations"
 	"github.com/grafana/grafana/pkg/setting"
@@ -79,4 +80,6 @@ var wireExtsSet = wire.NewSet(
 	encryptionprovider.ProvideEncryptionProvider,
 	wire.Bind(new(encryption.Provider), new(encryptionprovider.Provider)),
 	pluginsintegration.WireExtensionSet,
+	updatechecker.ProvideKVResultStore,
+	wire.Bind(new(updatechecker.ResultStore), new(*updatechecker.KVResultStore)),
 )
@@ -189,6 +189,7 @@ var wireBasicSet = wire.NewSet(
 	dashboardthumbsimpl.ProvideService,
 	updatechecker.ProvideGrafanaService,
 	updatechecker.ProvidePluginsService,
+	updatechecker.ProvideUpdateCheckers,
 	uss.ProvideService,
 	wire.Bind(new(usagestats.Service), new(*uss.UsageStats)),
 	pluginsintegration.WireSet,
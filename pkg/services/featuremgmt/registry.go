@@ -399,5 +399,11 @@ var (
 			FrontendOnly: true,
 			Owner:        "O11y-metrics",
 		},
+		{
+			Name:        "updateCheckerNotifications",
+			Description: "Notify a contact point when the update checker finds a newer Grafana version",
+			State:       FeatureStateAlpha,
+			Owner:       grafanaBackendPlatformSquad,
+		},
 	}
 )
@@ -270,4 +270,8 @@ const (
 	// FlagPrometheusMetricEncyclopedia
 	// Replaces the Prometheus query builder metric select option with a paginated and filterable component
 	FlagPrometheusMetricEncyclopedia = "prometheusMetricEncyclopedia"
+
+	// FlagUpdateCheckerNotifications
+	// Notify a contact point when the update checker finds a newer Grafana version
+	FlagUpdateCheckerNotifications = "updateCheckerNotifications"
 )
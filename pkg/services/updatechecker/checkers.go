@@ -0,0 +1,63 @@
+package updatechecker
+
+import (
+	"context"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// UpdateCheckers runs the Grafana and plugin update checkers as a single
+// background service, so that they don't have to be wired independently.
+type UpdateCheckers struct {
+	grafana *GrafanaService
+	plugins *PluginsService
+}
+
+func ProvideUpdateCheckers(grafana *GrafanaService, plugins *PluginsService) *UpdateCheckers {
+	return &UpdateCheckers{
+		grafana: grafana,
+		plugins: plugins,
+	}
+}
+
+// Grafana returns the Grafana update checker.
+func (c *UpdateCheckers) Grafana() *GrafanaService {
+	return c.grafana
+}
+
+// Plugins returns the plugin update checker.
+func (c *UpdateCheckers) Plugins() *PluginsService {
+	return c.plugins
+}
+
+// IsDisabled reports whether both update checkers are disabled.
+func (c *UpdateCheckers) IsDisabled() bool {
+	return c.grafana.IsDisabled() && c.plugins.IsDisabled()
+}
+
+// Run runs each enabled update checker on its own schedule until ctx is done.
+func (c *UpdateCheckers) Run(ctx context.Context) error {
+	var g errgroup.Group
+	if !c.grafana.IsDisabled() {
+		g.Go(func() error { return c.grafana.Run(ctx) })
+	}
+	if !c.plugins.IsDisabled() {
+		g.Go(func() error { return c.plugins.Run(ctx) })
+	}
+	if err := g.Wait(); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// GrafanaUpdate returns the latest Grafana version and whether it's an update
+// over the running version.
+func (c *UpdateCheckers) GrafanaUpdate() (string, bool) {
+	return c.grafana.LatestVersion(), c.grafana.UpdateAvailable()
+}
+
+// PluginUpdate returns the latest version of the given plugin and whether it's
+// an update over the installed version.
+func (c *UpdateCheckers) PluginUpdate(ctx context.Context, pluginID string) (string, bool) {
+	return c.plugins.HasUpdate(ctx, pluginID)
+}
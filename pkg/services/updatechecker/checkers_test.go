@@ -0,0 +1,88 @@
+package updatechecker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/plugins"
+)
+
+func newTestPluginsService(resp string) *PluginsService {
+	return &PluginsService{
+		enabled:          true,
+		grafanaVersion:   "9.3.0",
+		availableUpdates: map[string]string{},
+		pluginStore: plugins.FakePluginStore{
+			PluginList: []plugins.PluginDTO{
+				{
+					JSONData: plugins.JSONData{
+						ID:   "test-ds",
+						Info: plugins.Info{Version: "0.9.0"},
+						Type: plugins.DataSource,
+					},
+					Class: plugins.External,
+				},
+			},
+		},
+		httpClient: &fakeHTTPClient{fakeResp: resp},
+		log:        log.NewNopLogger(),
+	}
+}
+
+func TestUpdateCheckers(t *testing.T) {
+	t.Run("runs both checkers, which report independently", func(t *testing.T) {
+		grafana := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"stable": "9.3.1"}`})
+		checked := make(chan struct{}, 1)
+		grafana.checkDoneFunc = func() { checked <- struct{}{} }
+		checkers := ProvideUpdateCheckers(grafana, newTestPluginsService(`[{"slug": "test-ds", "version": "1.0.0"}]`))
+		require.False(t, checkers.IsDisabled())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		runErr := make(chan error, 1)
+		go func() { runErr <- checkers.Run(ctx) }()
+
+		<-checked
+		latest, ok := checkers.GrafanaUpdate()
+		require.True(t, ok)
+		require.Equal(t, "9.3.1", latest)
+
+		require.Eventually(t, func() bool {
+			_, ok := checkers.PluginUpdate(context.Background(), "test-ds")
+			return ok
+		}, time.Second, 10*time.Millisecond)
+		update, _ := checkers.PluginUpdate(context.Background(), "test-ds")
+		require.Equal(t, "1.0.0", update)
+
+		cancel()
+		require.ErrorIs(t, <-runErr, context.Canceled)
+	})
+
+	t.Run("only runs enabled checkers", func(t *testing.T) {
+		grafana := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"stable": "9.3.1"}`})
+		grafana.enabled = false
+		pluginsSvc := newTestPluginsService(`[{"slug": "test-ds", "version": "1.0.0"}]`)
+		checkers := ProvideUpdateCheckers(grafana, pluginsSvc)
+		require.False(t, checkers.IsDisabled())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		runErr := make(chan error, 1)
+		go func() { runErr <- checkers.Run(ctx) }()
+
+		require.Eventually(t, func() bool {
+			_, ok := checkers.PluginUpdate(context.Background(), "test-ds")
+			return ok
+		}, time.Second, 10*time.Millisecond)
+		_, ok := checkers.GrafanaUpdate()
+		require.False(t, ok)
+
+		cancel()
+		require.ErrorIs(t, <-runErr, context.Canceled)
+
+		pluginsSvc.enabled = false
+		require.True(t, checkers.IsDisabled())
+	})
+}
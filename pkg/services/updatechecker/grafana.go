@@ -2,104 +2,550 @@ package updatechecker
 
 import (
 	"context"
-	"encoding/json"
-	"io"
+	"fmt"
+	"net"
 	"net/http"
+	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/benbjohnson/clock"
 	"github.com/hashicorp/go-version"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/singleflight"
 
+	"github.com/grafana/grafana/pkg/infra/kvstore"
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/infra/usagestats"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/ngalert"
+	"github.com/grafana/grafana/pkg/services/supportbundles"
 	"github.com/grafana/grafana/pkg/setting"
 )
 
+const (
+	UpdateSeverityNone     = "none"
+	UpdateSeverityLow      = "low"
+	UpdateSeverityMedium   = "medium"
+	UpdateSeverityHigh     = "high"
+	UpdateSeverityCritical = "critical"
+)
+
 type GrafanaService struct {
-	hasUpdate     bool
-	latestVersion string
+	hasUpdate           bool
+	hasBetaUpdate       bool
+	previewVersion      string
+	latestVersion       string
+	parsedLatestVersion *version.Version
+	latestReleaseDate   time.Time
+	endOfLife           time.Time
+	requiresMigration   bool
+	upgradeHops         []string
+	recommendedVersion  string
+	hasApprovedList     bool
+	approvedVersion     string
+	approvedUpdate      bool
+	supportedLines      []string
+	changelogURL        string
+	vulnerabilities     []string
+	securityUpdate      bool
+	checkedSuccessfully bool
+	notifiedVersion     string
+	webhookVersion      string
+	dismissedVersion    string
+	bannerAckedAt       time.Time
+	snoozedUntil        time.Time
+	lastCheckAt         time.Time
+	consecutiveFailures int
+	lastSuccessAt       time.Time
+	mirrorDataStale     bool
+	ticker              *clock.Ticker
+	recheck             chan struct{}
+	tickerStartedAt     time.Time
+
+	lastPayload          []byte
+	lastPayloadTruncated bool
+	lastPayloadURL       string
+	lastPayloadAt        time.Time
+	lastParsedPayload    []byte
+	lastChanges          PayloadChanges
+	history              checkHistory
+	onCheckComplete      []func(CheckRecord)
+	etagCache            map[string]cachedResponse
+	digestCache          map[string]cachedDigest
+	cachedInstanceID     string
+	products             map[string]*productState
 
-	enabled        bool
-	grafanaVersion string
-	httpClient     http.Client
-	mutex          sync.RWMutex
-	log            log.Logger
+	enabled              bool
+	grafanaVersion       string
+	edition              string
+	parsedGrafanaVersion *version.Version
+	versionProvider      func() string
+	messageFormatter     MessageFormatter
+	deploymentChannel    string
+	deploymentType       string
+	bannerSuppressed     bool
+	nagInterval          time.Duration
+	ignoreTesting        bool
+	channelLadder        bool
+	notifyAboutBetas     bool
+	comparison           string
+	versionComponents    int
+	rebuildsAsUpdates    bool
+	buildCommit          string
+	buildStamp           time.Time
+	method               string
+	payloadKey           string
+	digestSuffix         string
+	artifactURLTemplate  string
+	defaultChangelogURL  string
+	approvedVersions     []string
+	maxPages             int
+	clockSkewTolerance   time.Duration
+	staleThreshold       time.Duration
+	maxMajorDistance     int
+	maxTestingLead       int
+	interval             time.Duration
+	maxBackoff           time.Duration
+	activeHours          *activeHours
+	alignChecks          bool
+	maxChecks            int
+	heartbeatInterval    time.Duration
+	traceConnections     bool
+	sendInstanceID       bool
+	region               string
+	timeouts             RequestTimeouts
+	testingOverrides     bool
+	assertInvariants     bool
+	mirrors              []MirrorStatus
+	httpClient           httpClient
+	kvStore              *kvstore.NamespacedKVStore
+	resultStore          ResultStore
+	notifier             newVersionNotifier
+	webhook              *webhookNotifier
+	webhookCalls         sync.WaitGroup
+	webhookCtx           context.Context
+	stopWebhooks         context.CancelFunc
+	clockUnsetLogged     atomic.Bool
+	tracer               tracing.Tracer
+	metrics              *grafanaMetrics
+	clock                clock.Clock
+	mutex                sync.RWMutex
+	checkGroup           singleflight.Group
+	log                  log.Logger
+
+	// checkDoneFunc is only used for tests: test code can set it to a non-nil
+	// function, and then it'll be called from the Run loop after every check.
+	checkDoneFunc func()
 }
 
-func ProvideGrafanaService(cfg *setting.Cfg) *GrafanaService {
-	return &GrafanaService{
-		enabled:        cfg.CheckForGrafanaUpdates,
-		grafanaVersion: cfg.BuildVersion,
-		httpClient:     http.Client{Timeout: 10 * time.Second},
-		log:            log.New("grafana.update.checker"),
+func ProvideGrafanaService(cfg *setting.Cfg, kvStore kvstore.KVStore, tracer tracing.Tracer, reg prometheus.Registerer, alertNG *ngalert.AlertNG, bundleRegistry supportbundles.Service, usageStats usagestats.Service, features featuremgmt.FeatureToggles, resultStore ResultStore) *GrafanaService {
+	return New(cfg, tracer,
+		WithKVStore(kvStore),
+		WithRegisterer(reg),
+		WithAlertNG(alertNG),
+		WithSupportBundles(bundleRegistry),
+		WithUsageStats(usageStats),
+		WithFeatures(features),
+		WithResultStore(resultStore),
+	)
+}
+
+// newGrafanaHTTPClient builds the client used for update checks. Besides the
+// overall request timeout, each connection phase gets its own timeout so that a
+// slow mirror can't hold a connection open by trickling bytes.
+func newGrafanaHTTPClient(cfg *setting.Cfg) *http.Client {
+	dialer := newGrafanaDialer(cfg)
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.dialContext,
+		TLSHandshakeTimeout:   cfg.UpdateCheckerTLSHandshakeTimeout,
+		ResponseHeaderTimeout: cfg.UpdateCheckerResponseHeaderTimeout,
+		IdleConnTimeout:       90 * time.Second,
+		MaxIdleConns:          1,
+	}
+	// A local agent can serve latest.json over a Unix domain socket, the URL
+	// still provides the path and Host header of requests.
+	if socket := strings.TrimPrefix(cfg.UpdateCheckerUnixSocket, "unix://"); socket != "" {
+		transport.Proxy = nil
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", socket)
+		}
 	}
+
+	return &http.Client{
+		Timeout:       cfg.UpdateCheckerTimeout,
+		Transport:     transport,
+		CheckRedirect: checkLatestRedirect,
+	}
+}
+
+// ipFamilies are the dial networks update checks can be restricted to.
+var ipFamilies = map[string]bool{
+	"tcp":  true,
+	"tcp4": true,
+	"tcp6": true,
+}
+
+// grafanaDialer dials update check connections over a fixed network, so that
+// they can be forced over IPv4 or IPv6 without affecting the rest of Grafana.
+type grafanaDialer struct {
+	net.Dialer
+	network string
+}
+
+func newGrafanaDialer(cfg *setting.Cfg) *grafanaDialer {
+	network := cfg.UpdateCheckerIPFamily
+	if !ipFamilies[network] {
+		network = "tcp"
+	}
+	return &grafanaDialer{
+		Dialer: net.Dialer{
+			Timeout:   cfg.UpdateCheckerDialTimeout,
+			KeepAlive: 30 * time.Second,
+		},
+		network: network,
+	}
+}
+
+func (d *grafanaDialer) dialContext(ctx context.Context, _, addr string) (net.Conn, error) {
+	return d.DialContext(ctx, d.network, addr)
+}
+
+// isFeatureEnabled reports whether flag is enabled, treating a missing
+// toggle service as all flags being disabled.
+func isFeatureEnabled(features featuremgmt.FeatureToggles, flag string) bool {
+	return features != nil && features.IsEnabled(flag)
+}
+
+// parseVersion parses v once so that getters don't have to, returning nil if
+// it isn't a valid version.
+func parseVersion(v string) *version.Version {
+	parsed, err := version.NewVersion(v)
+	if err != nil {
+		return nil
+	}
+	return parsed
+}
+
+// developmentVersions are build versions that don't name a release.
+var developmentVersions = map[string]bool{
+	"":        true,
+	"dev":     true,
+	"unknown": true,
+}
+
+func isDevelopmentVersion(v string) bool {
+	return developmentVersions[strings.ToLower(normalizeVersion(v))]
 }
 
 func (s *GrafanaService) IsDisabled() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
 	return !s.enabled
 }
 
+// SetEnabled turns update checks on or off at runtime. While disabled, the Run
+// loop skips its checks and checking on demand fails. A checker that was
+// disabled at startup isn't run at all, so enabling it later only allows
+// checking on demand.
+func (s *GrafanaService) SetEnabled(enabled bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if enabled != s.enabled {
+		s.log.Info("Grafana update checks toggled", "enabled", enabled)
+	}
+	s.enabled = enabled
+	s.metrics.enabled.Set(boolToFloat64(enabled))
+}
+
 func (s *GrafanaService) Run(ctx context.Context) error {
-	s.checkForUpdates()
+	ticker := s.startTicker()
+	defer s.stopTicker()
+	aligned := s.alignmentTimer()
+	heartbeat, stopHeartbeat := s.heartbeatTicker()
+	defer stopHeartbeat()
+
+	s.loadSnooze(ctx)
+	s.loadDismissal(ctx)
+	s.loadStatus(ctx)
+	checks := 0
+	if s.runCheck(ctx, s.clock.Now()) {
+		checks++
+	}
 
-	ticker := time.NewTicker(time.Minute * 10)
 	run := true
 
-	for run {
+	for run && (s.maxChecks <= 0 || checks < s.maxChecks) {
+		var checked bool
 		select {
-		case <-ticker.C:
-			s.checkForUpdates()
+		case tick := <-ticker.C:
+			checked = s.runCheck(ctx, tick)
+		case tick := <-aligned:
+			s.alignTicker(tick)
+			checked = s.runCheck(ctx, tick)
+		case <-s.recheck:
+			checked = s.runCheck(ctx, s.clock.Now())
+		case <-heartbeat:
+			s.logHeartbeat()
 		case <-ctx.Done():
 			run = false
 		}
+		if checked {
+			checks++
+		}
 	}
 
+	// Webhooks still being posted are given up on when Grafana shuts down,
+	// but not when the checker merely ran out of checks.
+	if ctx.Err() != nil {
+		s.stopWebhooks()
+	}
+	s.webhookCalls.Wait()
+
+	if ctx.Err() == nil {
+		s.log.Info("Update checker stopped after the maximum number of checks", "checks", checks)
+		return nil
+	}
+	// The cause tells an orderly shutdown apart from e.g. a deadline.
+	s.log.Info("Update checker stopped", "reason", ctx.Err(), "cause", contextCause(ctx))
 	return ctx.Err()
 }
 
-func (s *GrafanaService) checkForUpdates() {
-	resp, err := s.httpClient.Get("https://raw.githubusercontent.com/grafana/grafana/main/latest.json")
-	if err != nil {
-		s.log.Debug("Failed to get latest.json repo from github.com", "error", err)
-		return
+// runCheck checks for updates unless checks are currently held back, and
+// reports whether it did.
+func (s *GrafanaService) runCheck(ctx context.Context, tick time.Time) bool {
+	var checked bool
+	switch {
+	case s.IsDisabled():
+		s.log.Debug("Skipping update check while disabled")
+	case !s.activeHours.contains(tick):
+		s.log.Debug("Skipping update check outside active hours", "tick", tick)
+	case s.isSnoozed():
+		s.log.Debug("Skipping update check while snoozed", "until", s.SnoozedUntil())
+	case s.isBackingOff(tick):
+		s.log.Debug("Skipping update check while backing off after failures", "next", s.NextCheckAt())
+	default:
+		_ = s.sharedCheck(ctx, tick)
+		checked = true
 	}
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			s.log.Warn("Failed to close response body", "err", err)
-		}
-	}()
-	body, err := io.ReadAll(resp.Body)
+
+	if s.checkDoneFunc != nil {
+		s.checkDoneFunc()
+	}
+	return checked
+}
+
+func (s *GrafanaService) instrumentedCheckForUpdates(ctx context.Context) error {
+	// The span is what the exemplar of the duration links to.
+	ctx, span := s.tracer.Start(ctx, "updatechecker instrumentedCheckForUpdates")
+	defer span.End()
+
+	start := s.clock.Now()
+	result, err := s.checkForUpdates(ctx)
+	s.observeCheckDuration(ctx, s.clock.Since(start))
+	s.checkCompleted(s.recordHistory(start, err))
+
 	if err != nil {
-		s.log.Debug("Update check failed, reading response from github.com", "error", err)
-		return
+		s.log.Debug("Update check failed", "error", err)
+		s.metrics.checks.WithLabelValues("failure").Inc()
+		return err
+	}
+
+	s.metrics.checks.WithLabelValues(result.metricLabel()).Inc()
+	s.metrics.lastSuccess.Set(float64(s.clock.Now().Unix()))
+	s.metrics.updateAvailable.Set(boolToFloat64(s.UpdateAvailable()))
+	return nil
+}
+
+// checkResult describes the outcome of a successful check.
+type checkResult struct {
+	// changed is set when the check changed the update status.
+	changed bool
+	// notModified is set when the update server reported latest.json as
+	// unchanged since the previous check.
+	notModified bool
+}
+
+func (r checkResult) metricLabel() string {
+	switch {
+	case r.notModified:
+		return "not_modified"
+	case r.changed:
+		return "success_changed"
+	default:
+		return "success_unchanged"
 	}
+}
 
-	type latestJSON struct {
-		Stable  string `json:"stable"`
-		Testing string `json:"testing"`
+func (s *GrafanaService) checkForUpdates(ctx context.Context) (checkResult, error) {
+	ctx, span := s.tracer.Start(ctx, "updatechecker checkForUpdates")
+	defer span.End()
+
+	s.refreshVersion()
+	if err := s.checkProducts(ctx, span); err != nil {
+		s.log.Warn("Failed to check for product updates", "error", err)
 	}
-	var latest latestJSON
-	err = json.Unmarshal(body, &latest)
+	payload, url, statusCode, err := s.fetchLatest(ctx, span)
 	if err != nil {
-		s.log.Debug("Failed to unmarshal latest.json", "error", err)
-		return
+		return checkResult{}, err
+	}
+	return s.applyPayload(ctx, span, payload, url, statusCode)
+}
+
+// applyPayload updates the state from a latest.json fetched from url.
+func (s *GrafanaService) applyPayload(ctx context.Context, span tracing.Span, payload fetchedPayload, url string, statusCode int) (checkResult, error) {
+	s.recordPayload(payload, url)
+
+	latest, err := s.readLatestJSON(ctx, span, url, payload)
+	if err != nil {
+		return checkResult{}, err
+	}
+	stale := s.isMirrorDataStale(url, latest)
+
+	channel := s.latestChannel(latest)
+	latestVersion, parsedLatestVersion, hasUpdate := s.compare(latest, channel)
+	if hasUpdate && !s.isArtifactDownloadable(ctx, span, latestVersion) {
+		hasUpdate = false
 	}
+	previewVersion, hasPreview := s.newerTestingVersion(latest, channel)
+	hasBetaUpdate := s.betaUpdateAvailable(hasPreview)
+	approved := s.approvedList(latest)
+	approvedVersion, approvedUpdate := s.compareApproved(approved)
+	changes := s.changesSince(latest)
+	encoded := s.encodePayload(latest)
 
 	s.mutex.Lock()
-	defer s.mutex.Unlock()
-	if strings.Contains(s.grafanaVersion, "-") {
-		s.latestVersion = latest.Testing
-		s.hasUpdate = !strings.HasPrefix(s.grafanaVersion, latest.Testing)
+	s.lastParsedPayload = encoded
+	s.lastChanges = changes
+	prevLatest, prevHasUpdate := s.latestVersion, s.hasUpdate
+	prevRecommended, prevSecurity := s.recommendedVersion, s.securityUpdate
+	s.checkedSuccessfully = true
+	s.lastSuccessAt = s.clock.Now()
+	s.mirrorDataStale = stale
+	s.securityUpdate = latest.Security
+	s.recommendedVersion = latest.Recommended
+	s.supportedLines = latest.Supported
+	s.changelogURL = latest.ChangelogURLTemplate
+	s.vulnerabilities = s.affectingVulnerabilities(latest.Vulnerable)
+	s.latestVersion, s.parsedLatestVersion, s.hasUpdate = latestVersion, parsedLatestVersion, hasUpdate
+	s.hasBetaUpdate, s.previewVersion = hasBetaUpdate, previewVersion
+	s.hasApprovedList, s.approvedVersion, s.approvedUpdate = len(approved) > 0, approvedVersion, approvedUpdate
+
+	result := checkResult{
+		changed: s.latestVersion != prevLatest || s.hasUpdate != prevHasUpdate ||
+			s.recommendedVersion != prevRecommended || s.securityUpdate != prevSecurity,
+		notModified: statusCode == http.StatusNotModified,
+	}
+	// Both dates can't be malformed, validate has already checked them.
+	s.latestReleaseDate, _, _ = latest.releaseDate(s.latestVersion)
+	s.endOfLife, _, _ = latest.endOfLife(s.parsedGrafanaVersion)
+	s.requiresMigration = s.hasUpdate && latest.requiresMigration(s.parsedGrafanaVersion, s.parsedLatestVersion)
+	s.upgradeHops = latest.upgradeHops(s.parsedGrafanaVersion, s.parsedLatestVersion)
+
+	newVersion := s.hasUpdate && s.latestVersion != s.notifiedVersion
+	notifyVersion := s.latestVersion
+	// The webhook fires once per version, its retries are bounded by the
+	// webhook itself rather than by later checks.
+	newWebhookVersion := s.webhook != nil && s.hasUpdate && s.latestVersion != s.webhookVersion
+	if newWebhookVersion {
+		s.webhookVersion = s.latestVersion
+	}
+	detectedAt := s.lastSuccessAt
+	s.mutex.Unlock()
+	s.checkInvariants(latest, channel)
+
+	if !changes.IsEmpty() {
+		s.log.Info("Update server payload changed since the last check", "url", url, "stable", changes.NewStable,
+			"testing", changes.NewTesting, "newlySecurity", changes.NewlySecurity, "eol", changes.NewEOL)
+	}
+	if newVersion && s.notifier != nil {
+		s.notifyNewVersion(ctx, notifyVersion)
+	}
+	if newWebhookVersion {
+		grafanaVersion, _ := s.runningVersion()
+		s.callWebhook(webhookPayload{Current: grafanaVersion, Latest: notifyVersion, DetectedAt: detectedAt, Channel: channel})
+	}
+
+	return result, nil
+}
+
+// readLatestJSON validates a fetched payload, following pagination and
+// applying platform specific versions.
+func (s *GrafanaService) readLatestJSON(ctx context.Context, span tracing.Span, url string, payload fetchedPayload) (latestJSON, error) {
+	if payload.err != nil {
+		return latestJSON{}, fmt.Errorf("failed to unmarshal latest.json: %w", payload.err)
+	}
+	latest := payload.latest
+	if !isKnownSchemaVersion(latest.SchemaVersion) {
+		s.log.Warn("Update server uses an unknown latest.json schema version, reading it as the current one", "url", url, "schemaVersion", latest.SchemaVersion)
+	}
+
+	if err := s.fetchRemainingPages(ctx, span, url, &latest); err != nil {
+		return latestJSON{}, err
+	}
+	latest.fillFromReleases()
+	latest.applyPlatform(runtime.GOOS, runtime.GOARCH)
+
+	if err := validate(latest); err != nil {
+		return latestJSON{}, fmt.Errorf("invalid latest.json: %w", err)
+	}
+	s.dropAnomalousTesting(url, &latest)
+	if err := s.checkPlausible(latest); err != nil {
+		s.log.Error("Update server data doesn't look like it's for this product, ignoring it", "url", url, "error", err)
+		return latestJSON{}, fmt.Errorf("implausible latest.json: %w", err)
+	}
+	return latest, nil
+}
+
+// compare returns the latest version of the given channel and whether it's an
+// update over the running version. Apart from the running version it only
+// depends on the configuration, so it doesn't need the mutex.
+func (s *GrafanaService) compare(latest latestJSON, channel string) (string, *version.Version, bool) {
+	grafanaVersion, parsedGrafanaVersion := s.runningVersion()
+
+	var latestVersion string
+	var hasUpdate bool
+	if channel == channelTesting {
+		latestVersion = latest.Testing
+		hasUpdate = !strings.HasPrefix(normalizeVersion(grafanaVersion), normalizeVersion(latest.Testing))
 	} else {
-		s.latestVersion = latest.Stable
-		s.hasUpdate = latest.Stable != s.grafanaVersion
+		latestVersion = latest.Stable
+		hasUpdate = normalizeVersion(latest.Stable) != normalizeVersion(grafanaVersion)
+	}
+
+	parsedLatestVersion := parseVersion(latestVersion)
+	if parsedGrafanaVersion != nil && parsedLatestVersion != nil {
+		curr, latestV := s.withPrecision(parsedGrafanaVersion), s.withPrecision(parsedLatestVersion)
+		hasUpdate = s.isNewer(curr, latestV)
+		if !hasUpdate && s.rebuildsAsUpdates && curr.Equal(latestV) {
+			hasUpdate = s.isNewerBuild(latest.Builds[latestVersion])
+		}
+	}
+	if s.comparison == ComparisonServer && latest.UpdateAvailable != nil {
+		hasUpdate = *latest.UpdateAvailable
 	}
 
-	currVersion, err1 := version.NewVersion(s.grafanaVersion)
-	latestVersion, err2 := version.NewVersion(s.latestVersion)
-	if err1 == nil && err2 == nil {
-		s.hasUpdate = currVersion.LessThan(latestVersion)
+	return latestVersion, parsedLatestVersion, hasUpdate
+}
+
+// notifyNewVersion sends at most one notification per detected version. A
+// failed notification is retried on the next check.
+func (s *GrafanaService) notifyNewVersion(ctx context.Context, latestVersion string) {
+	grafanaVersion, _ := s.runningVersion()
+	if err := s.notifier.NotifyNewVersion(ctx, grafanaVersion, latestVersion); err != nil {
+		s.log.Warn("Failed to send update notification", "version", latestVersion, "error", err)
+		return
 	}
+
+	s.mutex.Lock()
+	s.notifiedVersion = latestVersion
+	s.mutex.Unlock()
 }
 
 func (s *GrafanaService) UpdateAvailable() bool {
@@ -113,3 +559,73 @@ func (s *GrafanaService) LatestVersion() string {
 	defer s.mutex.RUnlock()
 	return s.latestVersion
 }
+
+// SupportedVersionLines returns the version lines the update server lists as
+// supported, in the order it lists them. It's empty if the server doesn't
+// list any.
+func (s *GrafanaService) SupportedVersionLines() []string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	lines := make([]string, len(s.supportedLines))
+	copy(lines, s.supportedLines)
+	return lines
+}
+
+// RecommendedVersion returns the recommended upgrade target advertised by the
+// update server, if any.
+func (s *GrafanaService) RecommendedVersion() (string, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.recommendedVersion, s.recommendedVersion != ""
+}
+
+// RecommendedUpdateAvailable reports whether the running version is older than
+// the recommended version. Without a recommended version it is the same as
+// UpdateAvailable.
+func (s *GrafanaService) RecommendedUpdateAvailable() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if s.recommendedVersion == "" {
+		return s.hasUpdate
+	}
+	return canUpdate(s.grafanaVersion, s.recommendedVersion)
+}
+
+// HasCheckedSuccessfully reports whether at least one update check has
+// completed, which lets callers tell "no update" apart from "not checked yet".
+func (s *GrafanaService) HasCheckedSuccessfully() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.checkedSuccessfully
+}
+
+// UpdateSeverity classifies the available update by the semver delta between
+// the running and the latest version: a patch bump is low, a minor bump is
+// medium, a major bump is high and a security release is always critical.
+func (s *GrafanaService) UpdateSeverity() string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if !s.hasUpdate {
+		return UpdateSeverityNone
+	}
+	if s.securityUpdate {
+		return UpdateSeverityCritical
+	}
+
+	if s.parsedGrafanaVersion == nil || s.parsedLatestVersion == nil {
+		return UpdateSeverityLow
+	}
+
+	curr, latest := s.parsedGrafanaVersion.Segments(), s.parsedLatestVersion.Segments()
+	switch {
+	case latest[0] != curr[0]:
+		return UpdateSeverityHigh
+	case latest[1] != curr[1]:
+		return UpdateSeverityMedium
+	default:
+		return UpdateSeverityLow
+	}
+}
@@ -0,0 +1,73 @@
+package updatechecker
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// activeHours is a daily window during which update checks may run. A window
+// whose end is before its start crosses midnight.
+type activeHours struct {
+	// start and end are minutes since midnight, end is exclusive.
+	start, end int
+	loc        *time.Location
+}
+
+// parseActiveHours parses a window such as "09:00-17:00", optionally followed
+// by a time zone, e.g. "22:00-06:00 Europe/Berlin". Without a time zone the
+// local one is used. An empty window means checks may run at all hours.
+func parseActiveHours(s string) (*activeHours, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+
+	window, zone, _ := strings.Cut(s, " ")
+	from, to, ok := strings.Cut(window, "-")
+	if !ok {
+		return nil, fmt.Errorf("active hours %q aren't of the form HH:MM-HH:MM", s)
+	}
+
+	h := &activeHours{loc: time.Local}
+	var err error
+	if h.start, err = parseClockMinutes(from); err != nil {
+		return nil, err
+	}
+	if h.end, err = parseClockMinutes(to); err != nil {
+		return nil, err
+	}
+	if h.start == h.end {
+		return nil, fmt.Errorf("active hours %q are empty", s)
+	}
+
+	if zone = strings.TrimSpace(zone); zone != "" {
+		if h.loc, err = time.LoadLocation(zone); err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+func parseClockMinutes(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// contains reports whether t is within the window. A nil window contains all
+// times.
+func (h *activeHours) contains(t time.Time) bool {
+	if h == nil {
+		return true
+	}
+
+	t = t.In(h.loc)
+	m := t.Hour()*60 + t.Minute()
+	if h.start < h.end {
+		return m >= h.start && m < h.end
+	}
+	return m >= h.start || m < h.end
+}
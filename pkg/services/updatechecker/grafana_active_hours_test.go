@@ -0,0 +1,92 @@
+package updatechecker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestParseActiveHours(t *testing.T) {
+	h, err := parseActiveHours("")
+	require.NoError(t, err)
+	require.Nil(t, h)
+
+	h, err = parseActiveHours("09:00-17:30")
+	require.NoError(t, err)
+	require.Equal(t, &activeHours{start: 9 * 60, end: 17*60 + 30, loc: time.Local}, h)
+
+	h, err = parseActiveHours("22:00-06:00 Europe/Berlin")
+	require.NoError(t, err)
+	require.Equal(t, 22*60, h.start)
+	require.Equal(t, 6*60, h.end)
+	require.Equal(t, "Europe/Berlin", h.loc.String())
+
+	for _, invalid := range []string{"09:00", "9am-5pm", "09:00-25:00", "09:00-09:00", "09:00-17:00 Mars/Olympus"} {
+		_, err := parseActiveHours(invalid)
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestGrafanaUpdateChecker_activeHours(t *testing.T) {
+	day := time.Date(2023, time.March, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		window  string
+		inside  []time.Duration
+		outside []time.Duration
+	}{
+		{
+			name:    "daytime window",
+			window:  "09:00-17:00 UTC",
+			inside:  []time.Duration{9 * time.Hour, 12 * time.Hour, 16*time.Hour + 59*time.Minute},
+			outside: []time.Duration{0, 8*time.Hour + 59*time.Minute, 17 * time.Hour, 23 * time.Hour},
+		},
+		{
+			name:    "window crossing midnight",
+			window:  "22:00-06:00 UTC",
+			inside:  []time.Duration{22 * time.Hour, 23*time.Hour + 30*time.Minute, 0, 5*time.Hour + 59*time.Minute},
+			outside: []time.Duration{6 * time.Hour, 12 * time.Hour, 21*time.Hour + 59*time.Minute},
+		},
+		{
+			name:    "window in another time zone",
+			window:  "09:00-17:00 Europe/Berlin",
+			inside:  []time.Duration{8 * time.Hour, 15*time.Hour + 59*time.Minute},
+			outside: []time.Duration{7*time.Hour + 59*time.Minute, 16 * time.Hour},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := func(offset time.Duration) bool {
+				client := &scriptedHTTPClient{responses: []scriptedResponse{{body: `{"stable": "9.3.1"}`}}}
+				svc := newTestGrafanaService("9.3.0", client)
+				hours, err := parseActiveHours(tt.window)
+				require.NoError(t, err)
+				svc.activeHours = hours
+
+				mock := svc.clock.(*clock.Mock)
+				mock.Set(day.Add(offset))
+				svc.runCheck(context.Background(), mock.Now())
+				return client.requestCount() == 1
+			}
+
+			for _, offset := range tt.inside {
+				require.True(t, check(offset), "expected a check at %s", day.Add(offset).Format("15:04"))
+			}
+			for _, offset := range tt.outside {
+				require.False(t, check(offset), "expected no check at %s", day.Add(offset).Format("15:04"))
+			}
+		})
+	}
+
+	t.Run("invalid active hours check at all hours", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.UpdateCheckerActiveHours = "whenever"
+		require.Nil(t, provideTestGrafanaService(cfg).activeHours)
+	})
+}
@@ -0,0 +1,62 @@
+package updatechecker
+
+import (
+	"github.com/hashicorp/go-version"
+)
+
+// approvedList returns the versions approved for deployment, which the
+// operator can configure in place of the list of the update server.
+func (s *GrafanaService) approvedList(latest latestJSON) []string {
+	if len(s.approvedVersions) > 0 {
+		return s.approvedVersions
+	}
+	return latest.Approved
+}
+
+// compareApproved returns the newest of the approved versions and whether it's
+// an update over the running version. Versions that don't parse are skipped.
+func (s *GrafanaService) compareApproved(approved []string) (string, bool) {
+	var newest *version.Version
+	var newestVersion string
+	for _, v := range approved {
+		parsed := parseVersion(v)
+		if parsed == nil {
+			s.log.Debug("Skipping approved version that doesn't parse", "version", v)
+			continue
+		}
+		if newest == nil || parsed.GreaterThan(newest) {
+			newest, newestVersion = parsed, v
+		}
+	}
+
+	_, curr := s.runningVersion()
+	if newest == nil || curr == nil {
+		return newestVersion, false
+	}
+	return newestVersion, s.isNewer(s.withPrecision(curr), s.withPrecision(newest))
+}
+
+// LatestApprovedVersion returns the newest version approved for deployment.
+// Without an approved list it's the latest version.
+func (s *GrafanaService) LatestApprovedVersion() string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if !s.hasApprovedList {
+		return s.latestVersion
+	}
+	return s.approvedVersion
+}
+
+// ApprovedUpdateAvailable reports whether the newest version approved for
+// deployment is an update over the running version. Without an approved list
+// it is the same as UpdateAvailable.
+func (s *GrafanaService) ApprovedUpdateAvailable() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if !s.hasApprovedList {
+		return s.hasUpdate
+	}
+	return s.approvedUpdate
+}
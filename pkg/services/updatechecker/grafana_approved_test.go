@@ -0,0 +1,86 @@
+package updatechecker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestGrafanaUpdateChecker_approvedVersions(t *testing.T) {
+	tests := []struct {
+		name            string
+		configured      []string
+		resp            string
+		approvedVersion string
+		approvedUpdate  bool
+		hasUpdate       bool
+	}{
+		{
+			name:            "newest approved is older than the latest",
+			configured:      []string{"9.3.2", "9.4.1", "9.3.6"},
+			resp:            `{"stable": "9.5.0"}`,
+			approvedVersion: "9.4.1",
+			approvedUpdate:  true,
+			hasUpdate:       true,
+		},
+		{
+			name:            "running the newest approved version",
+			configured:      []string{"9.3.0"},
+			resp:            `{"stable": "9.5.0"}`,
+			approvedVersion: "9.3.0",
+			hasUpdate:       true,
+		},
+		{
+			name:            "approved list of the update server",
+			resp:            `{"stable": "9.5.0", "approved": ["9.3.6", "9.4.1"]}`,
+			approvedVersion: "9.4.1",
+			approvedUpdate:  true,
+			hasUpdate:       true,
+		},
+		{
+			name:            "configured list takes precedence",
+			configured:      []string{"9.3.6"},
+			resp:            `{"stable": "9.5.0", "approved": ["9.4.1"]}`,
+			approvedVersion: "9.3.6",
+			approvedUpdate:  true,
+			hasUpdate:       true,
+		},
+		{
+			name:            "unparsable versions are skipped",
+			configured:      []string{"latest", "9.3.6"},
+			resp:            `{"stable": "9.5.0"}`,
+			approvedVersion: "9.3.6",
+			approvedUpdate:  true,
+			hasUpdate:       true,
+		},
+		{
+			name:            "without a list the latest version is used",
+			resp:            `{"stable": "9.5.0"}`,
+			approvedVersion: "9.5.0",
+			approvedUpdate:  true,
+			hasUpdate:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: tt.resp})
+			svc.approvedVersions = tt.configured
+
+			require.NoError(t, svc.CheckNow(context.Background()))
+			require.Equal(t, tt.approvedVersion, svc.LatestApprovedVersion())
+			require.Equal(t, tt.approvedUpdate, svc.ApprovedUpdateAvailable())
+			require.Equal(t, tt.hasUpdate, svc.UpdateAvailable())
+			require.Equal(t, "9.5.0", svc.LatestVersion())
+		})
+	}
+
+	t.Run("is set up from the configuration", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.UpdateCheckerApprovedVersions = []string{"9.3.6"}
+		require.Equal(t, []string{"9.3.6"}, provideTestGrafanaService(cfg).approvedVersions)
+	})
+}
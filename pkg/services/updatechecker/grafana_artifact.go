@@ -0,0 +1,56 @@
+package updatechecker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/infra/tracing"
+)
+
+// artifactURL returns the configured artifact URL for the given version.
+func (s *GrafanaService) artifactURL(v string) string {
+	return strings.ReplaceAll(s.artifactURLTemplate, "{version}", normalizeVersion(v))
+}
+
+// isArtifactDownloadable checks with a HEAD request that the release artifact
+// of the given version can be downloaded, so that updates that can't be
+// fetched, e.g. in restricted networks, aren't advertised. It's always true
+// when no artifact URL is configured.
+func (s *GrafanaService) isArtifactDownloadable(ctx context.Context, span tracing.Span, v string) bool {
+	if s.artifactURLTemplate == "" {
+		return true
+	}
+
+	url := s.artifactURL(v)
+	err := s.headArtifact(ctx, span, url)
+	if err != nil {
+		s.log.Warn("Release artifact of the latest Grafana version isn't downloadable, not reporting the update", "version", v, "url", redactURL(url), "error", err)
+		return false
+	}
+	return true
+}
+
+func (s *GrafanaService) headArtifact(ctx context.Context, span tracing.Span, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return err
+	}
+	s.tracer.Inject(ctx, req.Header, span)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			s.log.Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
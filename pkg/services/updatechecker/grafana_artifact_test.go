@@ -0,0 +1,60 @@
+package updatechecker
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log/logtest"
+)
+
+func TestGrafanaUpdateChecker_artifactURL(t *testing.T) {
+	const artifact = "https://mirror.example.com/grafana-9.3.1.linux-amd64.tar.gz"
+
+	tests := []struct {
+		name       string
+		statusCode int
+		hasUpdate  bool
+		warnings   int
+	}{
+		{name: "downloadable artifact", statusCode: http.StatusOK, hasUpdate: true},
+		{name: "missing artifact", statusCode: http.StatusNotFound, hasUpdate: false, warnings: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &routingHTTPClient{routes: map[string]routedResponse{
+				defaultLatestJSONURL: {statusCode: http.StatusOK, body: `{"stable": "v9.3.1"}`},
+				artifact:             {statusCode: tt.statusCode},
+			}}
+			logger := &logtest.Fake{}
+			svc := newTestGrafanaService("9.3.0", client)
+			svc.log = logger
+			svc.artifactURLTemplate = "https://mirror.example.com/grafana-{version}.linux-amd64.tar.gz"
+
+			_, err := svc.checkForUpdates(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, tt.hasUpdate, svc.UpdateAvailable())
+			require.Equal(t, "v9.3.1", svc.LatestVersion())
+			require.Equal(t, []string{defaultLatestJSONURL, artifact}, client.requested)
+			require.Equal(t, tt.warnings, logger.WarnLogs.Calls)
+			if tt.warnings > 0 {
+				require.Equal(t, "Release artifact of the latest Grafana version isn't downloadable, not reporting the update", logger.WarnLogs.Message)
+			}
+		})
+	}
+
+	t.Run("not checked without an update", func(t *testing.T) {
+		client := &routingHTTPClient{routes: map[string]routedResponse{
+			defaultLatestJSONURL: {statusCode: http.StatusOK, body: `{"stable": "9.3.0"}`},
+		}}
+		svc := newTestGrafanaService("9.3.0", client)
+		svc.artifactURLTemplate = "https://mirror.example.com/grafana-{version}.linux-amd64.tar.gz"
+
+		_, err := svc.checkForUpdates(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, []string{defaultLatestJSONURL}, client.requested)
+	})
+}
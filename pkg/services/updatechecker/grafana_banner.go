@@ -0,0 +1,48 @@
+package updatechecker
+
+import (
+	"fmt"
+)
+
+// MessageFormatter renders the update banner message from the update status.
+// An empty message means there's nothing to show.
+type MessageFormatter func(status UpdateStatus) string
+
+// DefaultMessageFormatter announces available updates, calling out security
+// fixes.
+func DefaultMessageFormatter(status UpdateStatus) string {
+	switch {
+	case !status.UpdateAvailable:
+		return ""
+	case status.SecurityUpdate:
+		return fmt.Sprintf("Grafana %s is available and includes security fixes. You are running %s.", status.LatestVersion, status.CurrentVersion)
+	default:
+		return fmt.Sprintf("Grafana %s is available. You are running %s.", status.LatestVersion, status.CurrentVersion)
+	}
+}
+
+// SetMessageFormatter replaces the formatter of the banner message, e.g. for
+// editions with their own copy. A nil formatter restores the default one.
+func (s *GrafanaService) SetMessageFormatter(fn MessageFormatter) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.messageFormatter = fn
+}
+
+// BannerMessage returns the update banner message for the current status. It's
+// empty while the latest version is dismissed and in managed mode.
+func (s *GrafanaService) BannerMessage() string {
+	if s.bannerSuppressed || s.isDismissed() {
+		return ""
+	}
+
+	s.mutex.RLock()
+	format := s.messageFormatter
+	s.mutex.RUnlock()
+	if format == nil {
+		format = DefaultMessageFormatter
+	}
+
+	return format(s.Status())
+}
@@ -0,0 +1,38 @@
+package updatechecker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrafanaUpdateChecker_BannerMessage(t *testing.T) {
+	t.Run("default message", func(t *testing.T) {
+		client := &fakeHTTPClient{fakeResp: `{"stable": "9.3.0"}`}
+		svc := newTestGrafanaService("9.3.0", client)
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.Empty(t, svc.BannerMessage())
+
+		client.fakeResp = `{"stable": "9.3.1"}`
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.Equal(t, "Grafana 9.3.1 is available. You are running 9.3.0.", svc.BannerMessage())
+
+		client.fakeResp = `{"stable": "9.3.2", "security": true}`
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.Equal(t, "Grafana 9.3.2 is available and includes security fixes. You are running 9.3.0.", svc.BannerMessage())
+	})
+
+	t.Run("custom formatter", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"stable": "9.3.1"}`})
+		require.NoError(t, svc.CheckNow(context.Background()))
+
+		svc.SetMessageFormatter(func(status UpdateStatus) string {
+			return "Update available: upgrade to " + status.LatestVersion
+		})
+		require.Equal(t, "Update available: upgrade to 9.3.1", svc.BannerMessage())
+
+		svc.SetMessageFormatter(nil)
+		require.Equal(t, "Grafana 9.3.1 is available. You are running 9.3.0.", svc.BannerMessage())
+	})
+}
@@ -0,0 +1,43 @@
+package updatechecker
+
+import (
+	"time"
+)
+
+// BehindSummary returns how many minor releases the running version is behind
+// the latest one, and how long ago the latest one was released, for banners
+// along the lines of "you are 3 releases and 45 days behind". ok is false when
+// that can't be told: the versions don't parse, they differ in major version,
+// the payload has no release date for the latest version, or the local clock
+// isn't set. A release date up to the clock skew tolerance in the future
+// counts as released just now.
+func (s *GrafanaService) BehindSummary() (releases int, age time.Duration, ok bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if s.parsedGrafanaVersion == nil || s.parsedLatestVersion == nil {
+		return 0, 0, false
+	}
+	if !s.parsedGrafanaVersion.LessThan(s.parsedLatestVersion) {
+		return 0, 0, true
+	}
+
+	curr, latest := s.parsedGrafanaVersion.Segments(), s.parsedLatestVersion.Segments()
+	if curr[0] != latest[0] || s.latestReleaseDate.IsZero() {
+		return 0, 0, false
+	}
+
+	now := s.clock.Now()
+	if s.clockUnset(now) {
+		return 0, 0, false
+	}
+	age = now.Sub(s.latestReleaseDate)
+	if age < 0 {
+		if -age > s.clockSkewTolerance {
+			return 0, 0, false
+		}
+		age = 0
+	}
+
+	return latest[1] - curr[1], age, true
+}
@@ -0,0 +1,106 @@
+package updatechecker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrafanaUpdateChecker_BehindSummary(t *testing.T) {
+	now := time.Date(2023, time.March, 31, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		grafanaVersion string
+		payload        string
+		releases       int
+		age            time.Duration
+		ok             bool
+	}{
+		{
+			name:           "complete data",
+			grafanaVersion: "9.1.4",
+			payload:        `{"stable": "9.4.7", "releaseDates": {"9.4.7": "2023-03-01T12:00:00Z"}}`,
+			releases:       3,
+			age:            30 * 24 * time.Hour,
+			ok:             true,
+		},
+		{
+			name:           "date only release date",
+			grafanaVersion: "9.3.0",
+			payload:        `{"stable": "9.4.7", "releaseDates": {"9.4.7": "2023-03-30"}}`,
+			releases:       1,
+			age:            36 * time.Hour,
+			ok:             true,
+		},
+		{
+			name:           "patch release",
+			grafanaVersion: "9.4.6",
+			payload:        `{"stable": "9.4.7", "releaseDates": {"9.4.7": "2023-03-31T00:00:00Z"}}`,
+			releases:       0,
+			age:            12 * time.Hour,
+			ok:             true,
+		},
+		{
+			name:           "up to date",
+			grafanaVersion: "9.4.7",
+			payload:        `{"stable": "9.4.7"}`,
+			ok:             true,
+		},
+		{
+			name:           "release date within the clock skew tolerance",
+			grafanaVersion: "9.3.0",
+			payload:        `{"stable": "9.4.7", "releaseDates": {"9.4.7": "2023-03-31T12:03:00Z"}}`,
+			releases:       1,
+			ok:             true,
+		},
+		{
+			name:           "release date too far in the future",
+			grafanaVersion: "9.3.0",
+			payload:        `{"stable": "9.4.7", "releaseDates": {"9.4.7": "2023-04-30T12:00:00Z"}}`,
+		},
+		{
+			name:           "missing release date",
+			grafanaVersion: "9.3.0",
+			payload:        `{"stable": "9.4.7", "releaseDates": {"9.4.6": "2023-03-01"}}`,
+		},
+		{
+			name:           "major version behind",
+			grafanaVersion: "8.5.0",
+			payload:        `{"stable": "9.4.7", "releaseDates": {"9.4.7": "2023-03-01"}}`,
+		},
+		{
+			name:           "unparseable running version",
+			grafanaVersion: "main",
+			payload:        `{"stable": "9.4.7", "releaseDates": {"9.4.7": "2023-03-01"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestGrafanaService(tt.grafanaVersion, &fakeHTTPClient{fakeResp: tt.payload})
+			svc.clockSkewTolerance = 5 * time.Minute
+			mock := clock.NewMock()
+			mock.Set(now)
+			svc.clock = mock
+
+			_, err := svc.checkForUpdates(context.Background())
+			require.NoError(t, err)
+
+			releases, age, ok := svc.BehindSummary()
+			require.Equal(t, tt.ok, ok)
+			require.Equal(t, tt.releases, releases)
+			require.Equal(t, tt.age, age)
+		})
+	}
+
+	t.Run("no data before the first check", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{})
+
+		_, _, ok := svc.BehindSummary()
+		require.False(t, ok)
+	})
+}
@@ -0,0 +1,40 @@
+package updatechecker
+
+// newerTestingVersion returns the latest testing release when it's newer than a
+// build tracking the stable channel.
+func (s *GrafanaService) newerTestingVersion(latest latestJSON, channel string) (string, bool) {
+	if channel != channelStable || latest.Testing == "" {
+		return "", false
+	}
+	// The server's verdict is about the channel the instance tracks.
+	latest.UpdateAvailable = nil
+	testingVersion, _, newer := s.compare(latest, channelTesting)
+	if !newer {
+		return "", false
+	}
+	return testingVersion, true
+}
+
+// betaUpdateAvailable reports whether a stable build opted into beta notices
+// is older than the latest testing release.
+func (s *GrafanaService) betaUpdateAvailable(hasPreview bool) bool {
+	return hasPreview && s.notifyAboutBetas && !s.ignoreTesting
+}
+
+// BetaUpdateAvailable reports whether a newer testing release exists for a
+// stable build opted into beta notices. It's independent of UpdateAvailable,
+// which only considers the stable channel on such builds.
+func (s *GrafanaService) BetaUpdateAvailable() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.hasBetaUpdate
+}
+
+// PreviewVersion returns the latest testing release when it's newer than the
+// running version of a build tracking the stable channel, for APIs offering a
+// preview. It's reported regardless of beta notices and never shows a banner.
+func (s *GrafanaService) PreviewVersion() (string, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.previewVersion, s.previewVersion != ""
+}
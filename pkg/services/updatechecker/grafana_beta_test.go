@@ -0,0 +1,141 @@
+package updatechecker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestGrafanaUpdateChecker_BetaUpdateAvailable(t *testing.T) {
+	tests := []struct {
+		name          string
+		version       string
+		optIn         bool
+		ignoreTesting bool
+		resp          string
+		hasUpdate     bool
+		betaUpdate    bool
+	}{
+		{
+			name:       "stable build with a newer beta",
+			version:    "9.3.0",
+			optIn:      true,
+			resp:       `{"stable": "9.3.0", "testing": "9.4.0-beta1"}`,
+			betaUpdate: true,
+		},
+		{
+			name:       "stable and beta updates are reported separately",
+			version:    "9.3.0",
+			optIn:      true,
+			resp:       `{"stable": "9.3.1", "testing": "9.4.0-beta1"}`,
+			hasUpdate:  true,
+			betaUpdate: true,
+		},
+		{
+			name:    "beta older than the running version",
+			version: "9.4.0",
+			optIn:   true,
+			resp:    `{"stable": "9.4.0", "testing": "9.4.0-beta1"}`,
+		},
+		{
+			name:    "not opted in",
+			version: "9.3.0",
+			resp:    `{"stable": "9.3.0", "testing": "9.4.0-beta1"}`,
+		},
+		{
+			name:          "ignoring testing",
+			version:       "9.3.0",
+			optIn:         true,
+			ignoreTesting: true,
+			resp:          `{"stable": "9.3.0", "testing": "9.4.0-beta1"}`,
+		},
+		{
+			name:      "pre-release builds already track testing",
+			version:   "9.4.0-beta1",
+			optIn:     true,
+			resp:      `{"stable": "9.3.0", "testing": "9.4.0-beta2"}`,
+			hasUpdate: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestGrafanaService(tt.version, &fakeHTTPClient{fakeResp: tt.resp})
+			svc.notifyAboutBetas = tt.optIn
+			svc.ignoreTesting = tt.ignoreTesting
+
+			require.NoError(t, svc.CheckNow(context.Background()))
+			require.Equal(t, tt.hasUpdate, svc.UpdateAvailable())
+			require.Equal(t, tt.betaUpdate, svc.BetaUpdateAvailable())
+		})
+	}
+
+	t.Run("is set up from the configuration", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.NotifyAboutBetas = true
+		require.True(t, provideTestGrafanaService(cfg).notifyAboutBetas)
+	})
+}
+
+func TestGrafanaUpdateChecker_PreviewVersion(t *testing.T) {
+	tests := []struct {
+		name          string
+		version       string
+		ignoreTesting bool
+		resp          string
+		preview       string
+	}{
+		{
+			name:    "testing newer than the running stable",
+			version: "9.3.0",
+			resp:    `{"stable": "9.3.0", "testing": "9.4.0-beta1"}`,
+			preview: "9.4.0-beta1",
+		},
+		{
+			name:    "alongside a stable update",
+			version: "9.3.0",
+			resp:    `{"stable": "9.3.1", "testing": "9.4.0-beta1"}`,
+			preview: "9.4.0-beta1",
+		},
+		{
+			name:          "also when ignoring testing",
+			version:       "9.3.0",
+			ignoreTesting: true,
+			resp:          `{"stable": "9.3.0", "testing": "9.4.0-beta1"}`,
+			preview:       "9.4.0-beta1",
+		},
+		{
+			name:    "testing older than the running stable",
+			version: "9.4.0",
+			resp:    `{"stable": "9.4.0", "testing": "9.4.0-beta1"}`,
+		},
+		{
+			name:    "no testing release",
+			version: "9.3.0",
+			resp:    `{"stable": "9.3.0"}`,
+		},
+		{
+			name:    "testing build",
+			version: "9.4.0-beta1",
+			resp:    `{"stable": "9.3.0", "testing": "9.4.0-beta2"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestGrafanaService(tt.version, &fakeHTTPClient{fakeResp: tt.resp})
+			svc.ignoreTesting = tt.ignoreTesting
+			require.NoError(t, svc.CheckNow(context.Background()))
+
+			preview, ok := svc.PreviewVersion()
+			require.Equal(t, tt.preview != "", ok)
+			require.Equal(t, tt.preview, preview)
+			require.False(t, svc.BetaUpdateAvailable())
+			// A preview alone never shows the banner.
+			require.Equal(t, svc.UpdateAvailable(), svc.BannerMessage() != "")
+		})
+	}
+}
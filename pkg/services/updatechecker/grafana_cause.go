@@ -0,0 +1,13 @@
+//go:build !go1.20
+
+package updatechecker
+
+import (
+	"context"
+)
+
+// contextCause returns why ctx was cancelled. Before Go 1.20 contexts don't
+// carry a cause, so it's the error of the context.
+func contextCause(ctx context.Context) error {
+	return ctx.Err()
+}
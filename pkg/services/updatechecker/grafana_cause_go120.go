@@ -0,0 +1,12 @@
+//go:build go1.20
+
+package updatechecker
+
+import (
+	"context"
+)
+
+// contextCause returns why ctx was cancelled, see context.Cause.
+func contextCause(ctx context.Context) error {
+	return context.Cause(ctx)
+}
@@ -0,0 +1,32 @@
+//go:build go1.20
+
+package updatechecker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log/logtest"
+)
+
+func TestGrafanaUpdateChecker_Run_logsCancellationCause(t *testing.T) {
+	logger := &logtest.Fake{}
+	h := newRunHarness(t, "9.3.0", scriptedResponse{body: `{"stable": "9.3.0"}`})
+	h.svc.log = logger
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	go func() {
+		h.runErr <- h.svc.Run(ctx)
+	}()
+	h.waitForCheck()
+
+	cause := errors.New("server shutting down")
+	cancel(cause)
+	require.ErrorIs(t, <-h.runErr, context.Canceled)
+
+	require.Equal(t, "Update checker stopped", logger.InfoLogs.Message)
+	require.Equal(t, []interface{}{"reason", context.Canceled, "cause", cause}, logger.InfoLogs.Ctx)
+}
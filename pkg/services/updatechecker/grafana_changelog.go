@@ -0,0 +1,26 @@
+package updatechecker
+
+import (
+	"strings"
+)
+
+// ChangelogURL returns a link to the changes between the running and the
+// latest version, using the template of the update server or else the
+// configured one. ok is false without an update or without a template.
+func (s *GrafanaService) ChangelogURL() (url string, ok bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	template := s.changelogURL
+	if template == "" {
+		template = s.defaultChangelogURL
+	}
+	if !s.hasUpdate || template == "" {
+		return "", false
+	}
+
+	return strings.NewReplacer(
+		"{from}", normalizeVersion(s.grafanaVersion),
+		"{to}", normalizeVersion(s.latestVersion),
+	).Replace(template), true
+}
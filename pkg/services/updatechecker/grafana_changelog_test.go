@@ -0,0 +1,47 @@
+package updatechecker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrafanaUpdateChecker_ChangelogURL(t *testing.T) {
+	t.Run("substitutes the running and latest versions", func(t *testing.T) {
+		svc := newTestGrafanaService("v9.3.0", &fakeHTTPClient{fakeResp: `{"stable": "9.4.0", "changelogURLTemplate": "https://mirror.example.com/changelog?from={from}&to={to}"}`})
+		svc.defaultChangelogURL = "https://github.com/grafana/grafana/compare/v{from}...v{to}"
+		require.NoError(t, svc.CheckNow(context.Background()))
+
+		url, ok := svc.ChangelogURL()
+		require.True(t, ok)
+		require.Equal(t, "https://mirror.example.com/changelog?from=9.3.0&to=9.4.0", url)
+	})
+
+	t.Run("falls back to the configured template", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"stable": "9.4.0"}`})
+		svc.defaultChangelogURL = "https://github.com/grafana/grafana/compare/v{from}...v{to}"
+		require.NoError(t, svc.CheckNow(context.Background()))
+
+		url, ok := svc.ChangelogURL()
+		require.True(t, ok)
+		require.Equal(t, "https://github.com/grafana/grafana/compare/v9.3.0...v9.4.0", url)
+	})
+
+	t.Run("not available without a template", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"stable": "9.4.0"}`})
+		require.NoError(t, svc.CheckNow(context.Background()))
+
+		_, ok := svc.ChangelogURL()
+		require.False(t, ok)
+	})
+
+	t.Run("not available without an update", func(t *testing.T) {
+		svc := newTestGrafanaService("9.4.0", &fakeHTTPClient{fakeResp: `{"stable": "9.4.0", "changelogURLTemplate": "https://mirror.example.com/changelog?from={from}&to={to}"}`})
+		svc.defaultChangelogURL = "https://github.com/grafana/grafana/compare/v{from}...v{to}"
+		require.NoError(t, svc.CheckNow(context.Background()))
+
+		_, ok := svc.ChangelogURL()
+		require.False(t, ok)
+	})
+}
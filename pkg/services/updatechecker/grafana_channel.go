@@ -0,0 +1,69 @@
+package updatechecker
+
+import "strings"
+
+const (
+	channelStable  = "stable"
+	channelTesting = "testing"
+)
+
+// deploymentChannels maps deployment channel tags to the release channel
+// instances with that tag track.
+var deploymentChannels = map[string]string{
+	"canary":     channelTesting,
+	"staging":    channelStable,
+	"prod":       channelStable,
+	"production": channelStable,
+}
+
+// releaseChannel returns the release channel updates are compared against.
+// Ignoring testing always selects stable. Otherwise a known deployment channel
+// tag takes precedence over the implicit detection based on whether the
+// running version is a pre-release.
+func (s *GrafanaService) releaseChannel() string {
+	if s.ignoreTesting {
+		return channelStable
+	}
+	if channel, ok := s.deploymentReleaseChannel(); ok {
+		return channel
+	}
+	if grafanaVersion, _ := s.runningVersion(); isPreRelease(grafanaVersion) {
+		return channelTesting
+	}
+	return channelStable
+}
+
+// latestChannel is the release channel to compare latest against. With the
+// channel ladder, a pre-release that tracks testing only because it's a
+// pre-release moves to stable once its version line has a stable release,
+// e.g. 10.1.0-beta1 once 10.1.0 is out.
+func (s *GrafanaService) latestChannel(latest latestJSON) string {
+	channel := s.releaseChannel()
+	if !s.channelLadder || channel != channelTesting {
+		return channel
+	}
+	if _, ok := s.deploymentReleaseChannel(); ok {
+		return channel
+	}
+
+	_, running := s.runningVersion()
+	stable := parseVersion(latest.Stable)
+	if running != nil && stable != nil && stable.Prerelease() == "" && stable.GreaterThanOrEqual(running.Core()) {
+		return channelStable
+	}
+	return channel
+}
+
+// deploymentReleaseChannel returns the release channel of the deployment
+// channel tag, if it's a known one.
+func (s *GrafanaService) deploymentReleaseChannel() (string, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	channel, ok := deploymentChannels[strings.ToLower(s.deploymentChannel)]
+	return channel, ok
+}
+
+func isPreRelease(grafanaVersion string) bool {
+	return strings.Contains(grafanaVersion, "-")
+}
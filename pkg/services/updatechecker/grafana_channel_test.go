@@ -0,0 +1,150 @@
+package updatechecker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrafanaUpdateChecker_deploymentChannel(t *testing.T) {
+	const payload = `{"stable": "9.3.0", "testing": "9.4.0-beta1"}`
+
+	tests := []struct {
+		name              string
+		grafanaVersion    string
+		deploymentChannel string
+		ignoreTesting     bool
+		latestVersion     string
+		hasUpdate         bool
+	}{
+		{
+			name:              "canary tracks testing",
+			grafanaVersion:    "9.3.0",
+			deploymentChannel: "canary",
+			latestVersion:     "9.4.0-beta1",
+			hasUpdate:         true,
+		},
+		{
+			name:              "prod tracks stable",
+			grafanaVersion:    "9.4.0-beta1",
+			deploymentChannel: "prod",
+			latestVersion:     "9.3.0",
+			hasUpdate:         false,
+		},
+		{
+			name:              "channel tags are case insensitive",
+			grafanaVersion:    "9.3.0",
+			deploymentChannel: "Canary",
+			latestVersion:     "9.4.0-beta1",
+			hasUpdate:         true,
+		},
+		{
+			name:              "unknown tag falls back to pre-release detection",
+			grafanaVersion:    "9.4.0-alpha1",
+			deploymentChannel: "qa",
+			latestVersion:     "9.4.0-beta1",
+			hasUpdate:         true,
+		},
+		{
+			name:           "ignoring testing tracks stable on pre-release versions",
+			grafanaVersion: "9.4.0-alpha1",
+			ignoreTesting:  true,
+			latestVersion:  "9.3.0",
+			hasUpdate:      false,
+		},
+		{
+			name:              "ignoring testing overrides the deployment channel",
+			grafanaVersion:    "9.2.0",
+			deploymentChannel: "canary",
+			ignoreTesting:     true,
+			latestVersion:     "9.3.0",
+			hasUpdate:         true,
+		},
+		{
+			name:           "no tag tracks stable for stable versions",
+			grafanaVersion: "9.2.0",
+			latestVersion:  "9.3.0",
+			hasUpdate:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestGrafanaService(tt.grafanaVersion, &fakeHTTPClient{fakeResp: payload})
+			svc.deploymentChannel = tt.deploymentChannel
+			svc.ignoreTesting = tt.ignoreTesting
+
+			_, err := svc.checkForUpdates(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, tt.latestVersion, svc.LatestVersion())
+			require.Equal(t, tt.hasUpdate, svc.UpdateAvailable())
+		})
+	}
+}
+
+func TestGrafanaUpdateChecker_channelLadder(t *testing.T) {
+	tests := []struct {
+		name              string
+		grafanaVersion    string
+		payload           string
+		deploymentChannel string
+		ladder            bool
+		latestVersion     string
+		hasUpdate         bool
+	}{
+		{
+			name:           "pre-release whose line reached GA compares against stable",
+			grafanaVersion: "9.4.0-beta1",
+			payload:        `{"stable": "9.4.0", "testing": "9.5.0-beta1"}`,
+			ladder:         true,
+			latestVersion:  "9.4.0",
+			hasUpdate:      true,
+		},
+		{
+			name:           "nightly whose line reached GA compares against stable",
+			grafanaVersion: "9.4.0-12345pre",
+			payload:        `{"stable": "9.4.1", "testing": "9.5.0-beta1"}`,
+			ladder:         true,
+			latestVersion:  "9.4.1",
+			hasUpdate:      true,
+		},
+		{
+			name:           "pre-release whose line hasn't reached GA compares against testing",
+			grafanaVersion: "9.4.0-beta1",
+			payload:        `{"stable": "9.3.6", "testing": "9.4.0-beta2"}`,
+			ladder:         true,
+			latestVersion:  "9.4.0-beta2",
+			hasUpdate:      true,
+		},
+		{
+			name:           "without the ladder pre-releases compare against testing",
+			grafanaVersion: "9.4.0-beta1",
+			payload:        `{"stable": "9.4.0", "testing": "9.5.0-beta1"}`,
+			latestVersion:  "9.5.0-beta1",
+			hasUpdate:      true,
+		},
+		{
+			name:              "canary deployments keep tracking testing",
+			grafanaVersion:    "9.4.0-beta1",
+			payload:           `{"stable": "9.4.0", "testing": "9.5.0-beta1"}`,
+			deploymentChannel: "canary",
+			ladder:            true,
+			latestVersion:     "9.5.0-beta1",
+			hasUpdate:         true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestGrafanaService(tt.grafanaVersion, &fakeHTTPClient{fakeResp: tt.payload})
+			svc.deploymentChannel = tt.deploymentChannel
+			svc.channelLadder = tt.ladder
+
+			_, err := svc.checkForUpdates(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, tt.latestVersion, svc.LatestVersion())
+			require.Equal(t, tt.hasUpdate, svc.UpdateAvailable())
+		})
+	}
+}
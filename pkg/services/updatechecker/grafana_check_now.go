@@ -0,0 +1,95 @@
+package updatechecker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrChecksDisabled is returned when checking for updates on demand while the
+// checker is disabled.
+var ErrChecksDisabled = errors.New("grafana update checks are disabled")
+
+// CheckNow checks for updates right away, regardless of any snooze or
+// backoff. Calls made while a check is already in flight, including one
+// started by the Run loop, wait for that check and share its result instead
+// of requesting the update server again.
+func (s *GrafanaService) CheckNow(ctx context.Context) error {
+	if s.IsDisabled() {
+		return ErrChecksDisabled
+	}
+	return s.sharedCheck(ctx, s.clock.Now())
+}
+
+// CheckNowWithChannel checks the given release channel, "stable" or
+// "testing", regardless of the channel the instance tracks, e.g. to
+// troubleshoot a single check against testing. The outcome is returned
+// without changing the update status of the service. The request itself is a
+// regular one, though: it records the health of the mirrors and clock skew,
+// and fills the ETag and digest caches that later checks revalidate against.
+func (s *GrafanaService) CheckNowWithChannel(ctx context.Context, channel string) (UpdateStatus, error) {
+	if s.IsDisabled() {
+		return UpdateStatus{}, ErrChecksDisabled
+	}
+	if channel != channelStable && channel != channelTesting {
+		return UpdateStatus{}, fmt.Errorf("unknown release channel %q", channel)
+	}
+
+	ctx, span := s.tracer.Start(ctx, "updatechecker CheckNowWithChannel")
+	defer span.End()
+
+	s.refreshVersion()
+	payload, url, _, err := s.fetchLatest(ctx, span)
+	if err != nil {
+		return UpdateStatus{}, err
+	}
+	latest, err := s.readLatestJSON(ctx, span, url, payload)
+	if err != nil {
+		return UpdateStatus{}, err
+	}
+
+	latestVersion, _, hasUpdate := s.compare(latest, channel)
+	grafanaVersion, _ := s.runningVersion()
+	return UpdateStatus{
+		CurrentVersion:     grafanaVersion,
+		LatestVersion:      latestVersion,
+		RecommendedVersion: latest.Recommended,
+		UpdateAvailable:    hasUpdate,
+		SecurityUpdate:     latest.Security,
+		CheckedAt:          s.clock.Now(),
+	}, nil
+}
+
+// sharedCheck runs a check started at tick unless one is already in flight,
+// in which case it waits for the result of that one. The shared check runs
+// detached from the callers, bounded by the request timeout, so that the
+// caller that happened to start it can't cancel it for the others; each caller
+// stops waiting when its own context is done.
+func (s *GrafanaService) sharedCheck(ctx context.Context, tick time.Time) error {
+	results := s.checkGroup.DoChan("check", func() (interface{}, error) {
+		checkCtx, cancel := s.detachedCheckContext()
+		defer cancel()
+
+		err := s.instrumentedCheckForUpdates(checkCtx)
+		s.recordCheck(tick, err)
+		if err == nil {
+			s.saveStatus(checkCtx)
+		}
+		return nil, err
+	})
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case result := <-results:
+		return result.Err
+	}
+}
+
+func (s *GrafanaService) detachedCheckContext() (context.Context, context.CancelFunc) {
+	if s.timeouts.Total <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), s.timeouts.Total)
+}
@@ -0,0 +1,142 @@
+package updatechecker
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrafanaUpdateChecker_CheckNow(t *testing.T) {
+	t.Run("checks right away", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"stable": "9.3.1"}`})
+
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.True(t, svc.UpdateAvailable())
+		require.Equal(t, svc.clock.Now(), svc.lastCheckAt)
+	})
+
+	t.Run("fails when disabled", func(t *testing.T) {
+		client := &fakeHTTPClient{fakeResp: `{"stable": "9.3.1"}`}
+		svc := newTestGrafanaService("9.3.0", client)
+		svc.enabled = false
+
+		require.ErrorIs(t, svc.CheckNow(context.Background()), ErrChecksDisabled)
+		require.Empty(t, client.requestURL)
+	})
+
+	t.Run("concurrent calls share a single request", func(t *testing.T) {
+		client := &blockingHTTPClient{
+			body:    `{"stable": "9.3.1"}`,
+			started: make(chan struct{}),
+			release: make(chan struct{}),
+		}
+		svc := newTestGrafanaService("9.3.0", client)
+
+		const callers = 5
+		errs := make(chan error, callers)
+		go func() { errs <- svc.CheckNow(context.Background()) }()
+		<-client.started
+
+		var waiting sync.WaitGroup
+		for i := 1; i < callers; i++ {
+			waiting.Add(1)
+			go func() {
+				waiting.Done()
+				errs <- svc.CheckNow(context.Background())
+			}()
+		}
+		waiting.Wait()
+		// give the callers a moment to join the in-flight check
+		time.Sleep(50 * time.Millisecond)
+		close(client.release)
+
+		for i := 0; i < callers; i++ {
+			require.NoError(t, <-errs)
+		}
+		require.Equal(t, int32(1), atomic.LoadInt32(&client.requests))
+		require.True(t, svc.UpdateAvailable())
+	})
+
+	t.Run("the caller that started a shared check can't cancel it for the others", func(t *testing.T) {
+		client := &blockingHTTPClient{
+			body:    `{"stable": "9.3.1"}`,
+			started: make(chan struct{}),
+			release: make(chan struct{}),
+		}
+		svc := newTestGrafanaService("9.3.0", client)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		first := make(chan error, 1)
+		go func() { first <- svc.CheckNow(ctx) }()
+		<-client.started
+
+		second := make(chan error, 1)
+		go func() { second <- svc.CheckNow(context.Background()) }()
+		// give the second caller a moment to join the in-flight check
+		time.Sleep(50 * time.Millisecond)
+
+		cancel()
+		require.ErrorIs(t, <-first, context.Canceled)
+
+		close(client.release)
+		require.NoError(t, <-second)
+		require.NoError(t, client.ctxErr)
+		require.Equal(t, int32(1), atomic.LoadInt32(&client.requests))
+		require.True(t, svc.UpdateAvailable())
+	})
+}
+
+func TestGrafanaUpdateChecker_CheckNowWithChannel(t *testing.T) {
+	t.Run("checks testing on a stable instance without changing its state", func(t *testing.T) {
+		client := &fakeHTTPClient{fakeResp: `{"stable": "9.3.0", "testing": "9.4.0-beta1"}`}
+		svc := newTestGrafanaService("9.3.0", client)
+		require.NoError(t, svc.CheckNow(context.Background()))
+		before := svc.Status()
+
+		status, err := svc.CheckNowWithChannel(context.Background(), channelTesting)
+		require.NoError(t, err)
+		require.Equal(t, UpdateStatus{
+			CurrentVersion:  "9.3.0",
+			LatestVersion:   "9.4.0-beta1",
+			UpdateAvailable: true,
+			CheckedAt:       svc.clock.Now(),
+		}, status)
+
+		require.Equal(t, before, svc.Status())
+		require.Equal(t, "9.3.0", svc.LatestVersion())
+		require.False(t, svc.UpdateAvailable())
+	})
+
+	t.Run("shares mirror health and the response cache with regular checks", func(t *testing.T) {
+		client := &etagHTTPClient{etag: `"v1"`, body: `{"stable": "9.4.0", "testing": "9.5.0-beta1"}`}
+		svc := newTestGrafanaService("9.3.0", client)
+
+		status, err := svc.CheckNowWithChannel(context.Background(), channelTesting)
+		require.NoError(t, err)
+		require.Equal(t, "9.5.0-beta1", status.LatestVersion)
+		require.False(t, svc.UpdateAvailable())
+		mirrors := svc.Mirrors()
+		require.Equal(t, http.StatusOK, mirrors[0].LastStatusCode)
+		require.Equal(t, svc.clock.Now(), mirrors[0].LastSuccess)
+
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.Equal(t, `"v1"`, client.ifNoneMatch)
+		require.Equal(t, http.StatusNotModified, svc.Mirrors()[0].LastStatusCode)
+		require.Equal(t, "9.4.0", svc.LatestVersion())
+		require.True(t, svc.UpdateAvailable())
+	})
+
+	t.Run("rejects unknown channels", func(t *testing.T) {
+		client := &fakeHTTPClient{fakeResp: `{"stable": "9.3.0"}`}
+		svc := newTestGrafanaService("9.3.0", client)
+
+		_, err := svc.CheckNowWithChannel(context.Background(), "nightly")
+		require.EqualError(t, err, `unknown release channel "nightly"`)
+		require.Empty(t, client.requestURL)
+	})
+}
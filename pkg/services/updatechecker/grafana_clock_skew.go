@@ -0,0 +1,33 @@
+package updatechecker
+
+import (
+	"net/http"
+)
+
+// detectClockSkew warns when the Date header of an update server response
+// differs from the local clock by more than the configured tolerance, as date
+// based conclusions are unreliable on instances with a skewed clock.
+func (s *GrafanaService) detectClockSkew(url string, header http.Header) {
+	date := header.Get("Date")
+	if date == "" || s.clockSkewTolerance <= 0 {
+		return
+	}
+
+	remote, err := http.ParseTime(date)
+	if err != nil {
+		s.log.Debug("Failed to parse Date header of update server response", "url", url, "date", date, "error", err)
+		return
+	}
+
+	now := s.clock.Now()
+	if s.clockUnset(now) {
+		return
+	}
+	skew := now.Sub(remote)
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > s.clockSkewTolerance {
+		s.log.Warn("Local clock differs from the update server clock", "url", url, "skew", skew, "tolerance", s.clockSkewTolerance)
+	}
+}
@@ -0,0 +1,67 @@
+package updatechecker
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log/logtest"
+)
+
+func TestGrafanaUpdateChecker_detectClockSkew(t *testing.T) {
+	tests := []struct {
+		name     string
+		date     string
+		warnings int
+	}{
+		{
+			name:     "mirror clock matches local clock",
+			date:     "Mon, 13 Mar 2023 10:01:00 GMT",
+			warnings: 0,
+		},
+		{
+			name:     "mirror clock far ahead of local clock",
+			date:     "Mon, 13 Mar 2023 12:00:00 GMT",
+			warnings: 1,
+		},
+		{
+			name:     "mirror clock far behind local clock",
+			date:     "Sun, 12 Mar 2023 10:00:00 GMT",
+			warnings: 1,
+		},
+		{
+			name:     "no Date header",
+			warnings: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.date != "" {
+				header.Set("Date", tt.date)
+			}
+			client := &routingHTTPClient{routes: map[string]routedResponse{
+				defaultLatestJSONURL: {statusCode: http.StatusOK, header: header, body: `{"stable": "9.3.0"}`},
+			}}
+			logger := &logtest.Fake{}
+			svc := newTestGrafanaService("9.3.0", client)
+			svc.log = logger
+			svc.clockSkewTolerance = 5 * time.Minute
+			mock := clock.NewMock()
+			mock.Set(time.Date(2023, time.March, 13, 10, 0, 0, 0, time.UTC))
+			svc.clock = mock
+
+			_, err := svc.checkForUpdates(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, tt.warnings, logger.WarnLogs.Calls)
+			if tt.warnings > 0 {
+				require.Equal(t, "Local clock differs from the update server clock", logger.WarnLogs.Message)
+			}
+		})
+	}
+}
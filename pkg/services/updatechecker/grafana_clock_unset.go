@@ -0,0 +1,24 @@
+package updatechecker
+
+import (
+	"time"
+)
+
+// minPlausibleTime predates every release of the update checker, so a clock
+// that reads earlier, e.g. 1970 on a freshly booted appliance, hasn't been set.
+var minPlausibleTime = time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// clockUnset reports whether the local clock reads earlier than any plausible
+// time, in which case release ages, end of life and staleness are meaningless
+// and aren't computed. Version comparisons don't depend on the clock and are
+// unaffected. It's logged once until the clock is set.
+func (s *GrafanaService) clockUnset(now time.Time) bool {
+	if !now.Before(minPlausibleTime) {
+		s.clockUnsetLogged.Store(false)
+		return false
+	}
+	if !s.clockUnsetLogged.Swap(true) {
+		s.log.Warn("Local clock isn't set, skipping date based update conclusions", "now", now)
+	}
+	return true
+}
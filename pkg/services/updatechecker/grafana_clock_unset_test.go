@@ -0,0 +1,44 @@
+package updatechecker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrafanaUpdateChecker_clockUnset(t *testing.T) {
+	resp := `{
+		"stable": "9.4.0",
+		"releaseDates": {"9.4.0": "2023-02-28T00:00:00Z"},
+		"eolDates": {"9.3.x": "2023-06-01"},
+		"generatedAt": "2023-02-01T00:00:00Z"
+	}`
+	svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: resp})
+	svc.staleThreshold = 24 * time.Hour
+	svc.clockSkewTolerance = 5 * time.Minute
+	mock := svc.clock.(*clock.Mock)
+	mock.Set(time.Unix(0, 0))
+
+	require.NoError(t, svc.CheckNow(context.Background()))
+	require.True(t, svc.UpdateAvailable())
+	require.Equal(t, "9.4.0", svc.LatestVersion())
+	require.False(t, svc.MirrorDataStale())
+	_, _, ok := svc.BehindSummary()
+	require.False(t, ok)
+	_, ok = svc.TimeToEndOfLife()
+	require.False(t, ok)
+
+	mock.Set(time.Date(2023, time.March, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, svc.CheckNow(context.Background()))
+	require.True(t, svc.MirrorDataStale())
+	releases, age, ok := svc.BehindSummary()
+	require.True(t, ok)
+	require.Equal(t, 1, releases)
+	require.Equal(t, 24*time.Hour, age)
+	d, ok := svc.TimeToEndOfLife()
+	require.True(t, ok)
+	require.Equal(t, 92*24*time.Hour, d)
+}
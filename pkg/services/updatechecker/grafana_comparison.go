@@ -0,0 +1,126 @@
+package updatechecker
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-version"
+)
+
+const (
+	// ComparisonFull reports any newer version as an update.
+	ComparisonFull = "full"
+	// ComparisonMinor only reports versions with a greater major or minor
+	// version as an update, ignoring patch releases.
+	ComparisonMinor = "minor"
+	// ComparisonServer trusts the updateAvailable flag of payloads tailored
+	// to the requesting instance, e.g. by POST requests, and compares like
+	// ComparisonFull when the payload has no such flag.
+	ComparisonServer = "server"
+)
+
+// isNewer reports whether latest is an update over curr under the configured
+// comparison.
+func (s *GrafanaService) isNewer(curr, latest *version.Version) bool {
+	if s.comparison != ComparisonMinor {
+		return curr.LessThan(latest)
+	}
+
+	c, l := curr.Segments(), latest.Segments()
+	if l[0] != c[0] {
+		return l[0] > c[0]
+	}
+	return l[1] > c[1]
+}
+
+// withPrecision truncates or zero-pads v to the configured number of
+// components, keeping any pre-release and metadata, so that e.g. 10.3.0.1
+// and 10.3.0 compare equal with three components. Versions are compared as-is
+// when no precision is configured.
+func (s *GrafanaService) withPrecision(v *version.Version) *version.Version {
+	if s.versionComponents <= 0 {
+		return v
+	}
+
+	segments := v.Segments64()
+	parts := make([]string, s.versionComponents)
+	for i := range parts {
+		var n int64
+		if i < len(segments) {
+			n = segments[i]
+		}
+		parts[i] = strconv.FormatInt(n, 10)
+	}
+
+	normalized := strings.Join(parts, ".")
+	if pre := v.Prerelease(); pre != "" {
+		normalized += "-" + pre
+	}
+	if meta := v.Metadata(); meta != "" {
+		normalized += "+" + meta
+	}
+
+	nv, err := version.NewVersion(normalized)
+	if err != nil {
+		return v
+	}
+	return nv
+}
+
+// isNewerBuild reports whether build is a rebuild of the running version,
+// going by its timestamp when both builds have one and by its commit
+// otherwise.
+func (s *GrafanaService) isNewerBuild(build buildInfo) bool {
+	if build.Timestamp != "" && !s.buildStamp.IsZero() {
+		t, err := time.Parse(time.RFC3339, build.Timestamp)
+		return err == nil && t.After(s.buildStamp)
+	}
+	return build.Commit != "" && s.buildCommit != "" && build.Commit != s.buildCommit
+}
+
+func buildStamp(unix int64) time.Time {
+	if unix == 0 {
+		return time.Time{}
+	}
+	return time.Unix(unix, 0)
+}
+
+// normalizeVersion strips surrounding whitespace and a leading "v", so that
+// string comparisons of versions agree with go-version, which accepts both
+// "v10.3.0" and "10.3.0".
+func normalizeVersion(v string) string {
+	return strings.TrimPrefix(strings.TrimSpace(v), "v")
+}
+
+// InvalidVersionError is returned by CompareVersions for a version that
+// doesn't parse.
+type InvalidVersionError struct {
+	Version string
+	Err     error
+}
+
+func (e *InvalidVersionError) Error() string {
+	return fmt.Sprintf("invalid version %q: %s", e.Version, e.Err)
+}
+
+func (e *InvalidVersionError) Unwrap() error {
+	return e.Err
+}
+
+// CompareVersions compares two versions the way the update checker does,
+// ignoring surrounding whitespace and a leading "v". It returns -1, 0 or 1 if
+// a is older than, equal to or newer than b, and an *InvalidVersionError if
+// either doesn't parse.
+func CompareVersions(a, b string) (int, error) {
+	va, err := version.NewVersion(normalizeVersion(a))
+	if err != nil {
+		return 0, &InvalidVersionError{Version: a, Err: err}
+	}
+	vb, err := version.NewVersion(normalizeVersion(b))
+	if err != nil {
+		return 0, &InvalidVersionError{Version: b, Err: err}
+	}
+	return va.Compare(vb), nil
+}
@@ -0,0 +1,226 @@
+package updatechecker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestGrafanaUpdateChecker_comparison(t *testing.T) {
+	tests := []struct {
+		name           string
+		comparison     string
+		grafanaVersion string
+		stable         string
+		hasUpdate      bool
+	}{
+		{name: "full comparison reports patch releases", comparison: ComparisonFull, grafanaVersion: "9.3.0", stable: "9.3.1", hasUpdate: true},
+		{name: "default comparison reports patch releases", grafanaVersion: "9.3.0", stable: "9.3.1", hasUpdate: true},
+		{name: "minor comparison ignores patch releases", comparison: ComparisonMinor, grafanaVersion: "9.3.0", stable: "9.3.1", hasUpdate: false},
+		{name: "minor comparison reports minor releases", comparison: ComparisonMinor, grafanaVersion: "9.3.6", stable: "9.4.0", hasUpdate: true},
+		{name: "minor comparison reports major releases", comparison: ComparisonMinor, grafanaVersion: "9.5.2", stable: "10.0.0", hasUpdate: true},
+		{name: "minor comparison on an older major", comparison: ComparisonMinor, grafanaVersion: "10.0.0", stable: "9.5.2", hasUpdate: false},
+		{name: "minor comparison when up to date", comparison: ComparisonMinor, grafanaVersion: "9.4.0", stable: "9.4.0", hasUpdate: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestGrafanaService(tt.grafanaVersion, &fakeHTTPClient{fakeResp: `{"stable": "` + tt.stable + `"}`})
+			svc.comparison = tt.comparison
+
+			_, err := svc.checkForUpdates(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, tt.hasUpdate, svc.UpdateAvailable())
+			require.Equal(t, tt.stable, svc.LatestVersion())
+		})
+	}
+}
+
+func TestGrafanaUpdateChecker_vPrefixedVersions(t *testing.T) {
+	tests := []struct {
+		name           string
+		grafanaVersion string
+		payload        string
+	}{
+		{name: "v-prefixed stable", grafanaVersion: "10.3.0", payload: `{"stable": "v10.3.0"}`},
+		{name: "v-prefixed running version", grafanaVersion: "v10.3.0", payload: `{"stable": "10.3.0"}`},
+		{name: "running version with whitespace", grafanaVersion: " 10.3.0\n", payload: `{"stable": "v10.3.0"}`},
+		{name: "v-prefixed testing", grafanaVersion: "10.4.0-beta1", payload: `{"stable": "10.3.0", "testing": "v10.4.0-beta1"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Run("parsed comparison", func(t *testing.T) {
+				svc := newTestGrafanaService(tt.grafanaVersion, &fakeHTTPClient{fakeResp: tt.payload})
+				_, err := svc.checkForUpdates(context.Background())
+				require.NoError(t, err)
+				require.False(t, svc.UpdateAvailable())
+			})
+
+			t.Run("string comparison", func(t *testing.T) {
+				svc := newTestGrafanaService(tt.grafanaVersion, &fakeHTTPClient{fakeResp: tt.payload})
+				// Without a parsed running version only the string
+				// comparison decides.
+				svc.parsedGrafanaVersion = nil
+				_, err := svc.checkForUpdates(context.Background())
+				require.NoError(t, err)
+				require.False(t, svc.UpdateAvailable())
+			})
+		})
+	}
+}
+
+func TestNormalizeVersion(t *testing.T) {
+	require.Equal(t, "10.3.0", normalizeVersion("v10.3.0"))
+	require.Equal(t, "10.3.0", normalizeVersion(" v10.3.0 "))
+	require.Equal(t, "10.3.0", normalizeVersion("10.3.0"))
+	require.Equal(t, "", normalizeVersion(""))
+}
+
+func TestGrafanaUpdateChecker_rebuildsAsUpdates(t *testing.T) {
+	const payload = `{"stable": "9.3.0", "builds": {"9.3.0": {"commit": "def456", "timestamp": "2023-03-13T10:00:00Z"}}}`
+
+	tests := []struct {
+		name      string
+		enabled   bool
+		payload   string
+		commit    string
+		stamp     time.Time
+		hasUpdate bool
+	}{
+		{name: "newer build", enabled: true, payload: payload, commit: "abc123", stamp: time.Date(2023, time.March, 1, 0, 0, 0, 0, time.UTC), hasUpdate: true},
+		{name: "newer build when disabled", payload: payload, commit: "abc123", stamp: time.Date(2023, time.March, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "same build", enabled: true, payload: payload, commit: "def456", stamp: time.Date(2023, time.March, 13, 10, 0, 0, 0, time.UTC)},
+		{name: "older build", enabled: true, payload: payload, commit: "abc123", stamp: time.Date(2023, time.March, 20, 0, 0, 0, 0, time.UTC)},
+		{name: "different commit without build stamp", enabled: true, payload: payload, commit: "abc123", hasUpdate: true},
+		{name: "same commit without build stamp", enabled: true, payload: payload, commit: "def456"},
+		{name: "no build info", enabled: true, payload: `{"stable": "9.3.0"}`, commit: "abc123", stamp: time.Date(2023, time.March, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: tt.payload})
+			svc.rebuildsAsUpdates = tt.enabled
+			svc.buildCommit = tt.commit
+			svc.buildStamp = tt.stamp
+
+			_, err := svc.checkForUpdates(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, tt.hasUpdate, svc.UpdateAvailable())
+		})
+	}
+
+	t.Run("newer versions are updates regardless of builds", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"stable": "9.3.1"}`})
+		svc.rebuildsAsUpdates = true
+
+		_, err := svc.checkForUpdates(context.Background())
+		require.NoError(t, err)
+		require.True(t, svc.UpdateAvailable())
+	})
+}
+
+func TestGrafanaUpdateChecker_serverComparison(t *testing.T) {
+	tests := []struct {
+		name       string
+		comparison string
+		payload    string
+		hasUpdate  bool
+	}{
+		{name: "flag reports an update", comparison: ComparisonServer, payload: `{"stable": "9.3.0", "updateAvailable": true}`, hasUpdate: true},
+		{name: "flag suppresses an update", comparison: ComparisonServer, payload: `{"stable": "9.3.1", "updateAvailable": false}`, hasUpdate: false},
+		{name: "without flag compares locally", comparison: ComparisonServer, payload: `{"stable": "9.3.1"}`, hasUpdate: true},
+		{name: "without flag compares locally when up to date", comparison: ComparisonServer, payload: `{"stable": "9.3.0"}`, hasUpdate: false},
+		{name: "flag ignored by full comparison", comparison: ComparisonFull, payload: `{"stable": "9.3.1", "updateAvailable": false}`, hasUpdate: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: tt.payload})
+			svc.comparison = tt.comparison
+
+			_, err := svc.checkForUpdates(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, tt.hasUpdate, svc.UpdateAvailable())
+		})
+	}
+}
+
+func TestProvideGrafanaService_comparison(t *testing.T) {
+	for _, comparison := range []string{ComparisonFull, ComparisonMinor, ComparisonServer} {
+		cfg := setting.NewCfg()
+		cfg.UpdateCheckerComparison = comparison
+		require.Equal(t, comparison, provideTestGrafanaService(cfg).comparison)
+	}
+
+	cfg := setting.NewCfg()
+	cfg.UpdateCheckerComparison = "patch"
+	require.Equal(t, ComparisonFull, provideTestGrafanaService(cfg).comparison)
+}
+
+func TestGrafanaUpdateChecker_versionComponents(t *testing.T) {
+	tests := []struct {
+		name           string
+		components     int
+		grafanaVersion string
+		stable         string
+		hasUpdate      bool
+	}{
+		{name: "four components as-is", grafanaVersion: "10.3.0", stable: "10.3.0.1", hasUpdate: true},
+		{name: "four components normalized to three", components: 3, grafanaVersion: "10.3.0", stable: "10.3.0.1", hasUpdate: false},
+		{name: "running four components normalized to three", components: 3, grafanaVersion: "10.3.0.0", stable: "10.3.0", hasUpdate: false},
+		{name: "newer patch normalized to three", components: 3, grafanaVersion: "10.3.0.7", stable: "10.3.1", hasUpdate: true},
+		{name: "fewer components padded", components: 4, grafanaVersion: "10.3", stable: "10.3.0.1", hasUpdate: true},
+		{name: "pre-release kept", components: 3, grafanaVersion: "10.3.0.0-beta1", stable: "10.3.0", hasUpdate: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestGrafanaService(tt.grafanaVersion, &fakeHTTPClient{fakeResp: `{"stable": "` + tt.stable + `"}`})
+			svc.versionComponents = tt.components
+			svc.ignoreTesting = true
+
+			_, err := svc.checkForUpdates(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, tt.hasUpdate, svc.UpdateAvailable())
+		})
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     string
+		expected int
+		wantErr  bool
+		invalid  string
+	}{
+		{name: "equal", a: "9.3.0", b: "9.3.0", expected: 0},
+		{name: "missing components are zero", a: "9.3", b: "9.3.0", expected: 0},
+		{name: "less", a: "9.3.0", b: "9.3.1", expected: -1},
+		{name: "greater", a: "10.0.0", b: "9.5.2", expected: 1},
+		{name: "pre-release is older than the release", a: "10.0.0-beta1", b: "10.0.0", expected: -1},
+		{name: "v-prefixed", a: "v9.3.0", b: "9.3.0", expected: 0},
+		{name: "v-prefixed and surrounded by whitespace", a: " v9.4.0 ", b: "v9.3.0", expected: 1},
+		{name: "invalid first version", a: "latest", b: "9.3.0", wantErr: true, invalid: "latest"},
+		{name: "invalid second version", a: "9.3.0", b: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmp, err := CompareVersions(tt.a, tt.b)
+			if tt.wantErr {
+				var invalid *InvalidVersionError
+				require.ErrorAs(t, err, &invalid)
+				require.Equal(t, tt.invalid, invalid.Version)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, cmp)
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package updatechecker
+
+// SetMirrorURLs replaces the update servers to check, in the order they are
+// tried. An empty list falls back to the default update server. A running Run
+// loop re-checks right away instead of waiting for the next tick.
+func (s *GrafanaService) SetMirrorURLs(urls []string) {
+	s.mutex.Lock()
+	s.mirrors = newMirrorStatuses(urls)
+	s.mutex.Unlock()
+
+	s.requestRecheck()
+}
+
+// SetDeploymentChannel changes the deployment channel tag, see
+// releaseChannel. A running Run loop re-checks right away instead of waiting
+// for the next tick.
+func (s *GrafanaService) SetDeploymentChannel(channel string) {
+	s.mutex.Lock()
+	s.deploymentChannel = channel
+	s.mutex.Unlock()
+
+	s.requestRecheck()
+}
+
+// requestRecheck asks the Run loop to check again after a configuration
+// change. The request is buffered, so that changes in quick succession are
+// coalesced into a single check.
+func (s *GrafanaService) requestRecheck() {
+	select {
+	case s.recheck <- struct{}{}:
+	default:
+	}
+}
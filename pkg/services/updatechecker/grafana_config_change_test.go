@@ -0,0 +1,59 @@
+package updatechecker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrafanaUpdateChecker_configChange(t *testing.T) {
+	t.Run("rapid changes are coalesced into a single re-check", func(t *testing.T) {
+		const payload = `{"stable": "9.3.1", "testing": "9.4.0-beta1"}`
+		h := newRunHarness(t, "9.3.0", scriptedResponse{body: payload}, scriptedResponse{body: payload}, scriptedResponse{body: payload})
+
+		// Hold the Run loop in its first check while the configuration changes.
+		release := make(chan struct{})
+		checkDone := h.svc.checkDoneFunc
+		first := true
+		h.svc.checkDoneFunc = func() {
+			if first {
+				first = false
+				<-release
+			}
+			checkDone()
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		h.cancel = cancel
+		go func() { h.runErr <- h.svc.Run(ctx) }()
+		require.Eventually(t, func() bool { return h.client.requestCount() == 1 }, time.Second, time.Millisecond)
+
+		h.svc.SetMirrorURLs([]string{"https://mirror1.example.com/latest.json"})
+		h.svc.SetDeploymentChannel("canary")
+		h.svc.SetMirrorURLs([]string{"https://mirror2.example.com/latest.json"})
+		close(release)
+
+		h.waitForCheck()
+		h.waitForCheck()
+		require.Equal(t, 2, h.client.requestCount())
+		h.requireState("9.4.0-beta1", true)
+		require.Equal(t, "https://mirror2.example.com/latest.json", h.svc.Mirrors()[0].URL)
+
+		select {
+		case <-h.checked:
+			t.Fatal("expected a single re-check")
+		case <-time.After(50 * time.Millisecond):
+		}
+		require.ErrorIs(t, h.stop(), context.Canceled)
+	})
+
+	t.Run("changes without a running loop don't block", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"stable": "9.3.1"}`})
+		for i := 0; i < 3; i++ {
+			svc.SetDeploymentChannel("canary")
+		}
+		require.Equal(t, channelTesting, svc.releaseChannel())
+	})
+}
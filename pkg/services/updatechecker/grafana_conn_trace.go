@@ -0,0 +1,112 @@
+package updatechecker
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Connection phases timed by connection tracing.
+const (
+	connPhaseDNS     = "dns"
+	connPhaseConnect = "connect"
+	connPhaseTLS     = "tls"
+)
+
+// connStats holds what connection tracing learnt about a single request.
+// The trace hooks may be called from the goroutines of the transport, hence
+// the mutex.
+type connStats struct {
+	mutex  sync.Mutex
+	reused bool
+	phases map[string]time.Duration
+	starts map[string]time.Time
+}
+
+func newConnStats() *connStats {
+	return &connStats{
+		phases: map[string]time.Duration{},
+		starts: map[string]time.Time{},
+	}
+}
+
+func (c *connStats) start(phase string, now time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	// Dialing several addresses calls ConnectStart once per address, the
+	// first one is when connecting started.
+	if _, ok := c.starts[phase]; !ok {
+		c.starts[phase] = now
+	}
+}
+
+func (c *connStats) done(phase string, now time.Time, err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	start, ok := c.starts[phase]
+	if !ok || err != nil {
+		return
+	}
+	if _, ok := c.phases[phase]; !ok {
+		c.phases[phase] = now.Sub(start)
+	}
+}
+
+func (c *connStats) gotConn(info httptrace.GotConnInfo) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.reused = info.Reused
+}
+
+// newClientTrace returns hooks recording into stats whether the connection
+// was reused and how long DNS resolution, connecting and the TLS handshake
+// took.
+func (s *GrafanaService) newClientTrace(stats *connStats) *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { stats.start(connPhaseDNS, s.clock.Now()) },
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			stats.done(connPhaseDNS, s.clock.Now(), info.Err)
+		},
+		ConnectStart: func(string, string) { stats.start(connPhaseConnect, s.clock.Now()) },
+		ConnectDone: func(_, _ string, err error) {
+			stats.done(connPhaseConnect, s.clock.Now(), err)
+		},
+		TLSHandshakeStart: func() { stats.start(connPhaseTLS, s.clock.Now()) },
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			stats.done(connPhaseTLS, s.clock.Now(), err)
+		},
+		GotConn: stats.gotConn,
+	}
+}
+
+// traceConnection attaches connection tracing to req when it's enabled. The
+// returned function reports the stats once the request is done.
+func (s *GrafanaService) traceConnection(req *http.Request) (*http.Request, func()) {
+	if !s.traceConnections {
+		return req, func() {}
+	}
+
+	stats := newConnStats()
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), s.newClientTrace(stats)))
+	return req, func() { s.recordConnStats(req.URL.String(), stats) }
+}
+
+func (s *GrafanaService) recordConnStats(url string, stats *connStats) {
+	stats.mutex.Lock()
+	defer stats.mutex.Unlock()
+
+	s.log.Debug("Update check connection", "url", url, "reused", stats.reused,
+		"dns", stats.phases[connPhaseDNS], "connect", stats.phases[connPhaseConnect], "tls", stats.phases[connPhaseTLS])
+
+	if s.metrics.connections != nil {
+		s.metrics.connections.WithLabelValues(strconv.FormatBool(stats.reused)).Inc()
+	}
+	if s.metrics.connectionPhases != nil {
+		for phase, d := range stats.phases {
+			s.metrics.connectionPhases.WithLabelValues(phase).Observe(d.Seconds())
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package updatechecker
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log/logtest"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestGrafanaUpdateChecker_newClientTrace(t *testing.T) {
+	svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{})
+	mock := svc.clock.(*clock.Mock)
+	stats := newConnStats()
+	trace := svc.newClientTrace(stats)
+
+	trace.DNSStart(httptrace.DNSStartInfo{Host: "grafana.com"})
+	mock.Add(10 * time.Millisecond)
+	trace.DNSDone(httptrace.DNSDoneInfo{})
+	trace.ConnectStart("tcp", "192.0.2.1:443")
+	trace.ConnectStart("tcp", "[2001:db8::1]:443")
+	mock.Add(20 * time.Millisecond)
+	trace.ConnectDone("tcp", "[2001:db8::1]:443", errors.New("network is unreachable"))
+	mock.Add(5 * time.Millisecond)
+	trace.ConnectDone("tcp", "192.0.2.1:443", nil)
+	trace.TLSHandshakeStart()
+	mock.Add(30 * time.Millisecond)
+	trace.TLSHandshakeDone(tls.ConnectionState{}, nil)
+	trace.GotConn(httptrace.GotConnInfo{Reused: false})
+
+	require.False(t, stats.reused)
+	require.Equal(t, map[string]time.Duration{
+		connPhaseDNS:     10 * time.Millisecond,
+		connPhaseConnect: 25 * time.Millisecond,
+		connPhaseTLS:     30 * time.Millisecond,
+	}, stats.phases)
+}
+
+func TestGrafanaUpdateChecker_traceConnections(t *testing.T) {
+	t.Run("records a reused connection", func(t *testing.T) {
+		client := &tracingHTTPClient{reused: true}
+		logger := &logtest.Fake{}
+		svc := newTestGrafanaService("9.3.0", client)
+		svc.log = logger
+		svc.traceConnections = true
+		svc.metrics.registerConnectionMetrics(prometheus.NewRegistry())
+
+		_, err := svc.checkForUpdates(context.Background())
+		require.NoError(t, err)
+
+		require.True(t, client.traced)
+		require.Equal(t, 1.0, testutil.ToFloat64(svc.metrics.connections.WithLabelValues("true")))
+		require.Zero(t, testutil.CollectAndCount(svc.metrics.connectionPhases))
+		require.Contains(t, logger.DebugLogs.Ctx, "reused")
+		require.Equal(t, true, logger.DebugLogs.Ctx[3])
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		svc := provideTestGrafanaService(setting.NewCfg())
+		require.False(t, svc.traceConnections)
+		require.Nil(t, svc.metrics.connections)
+
+		client := &tracingHTTPClient{}
+		svc = newTestGrafanaService("9.3.0", client)
+		_, err := svc.checkForUpdates(context.Background())
+		require.NoError(t, err)
+		require.False(t, client.traced)
+	})
+
+	t.Run("registers the connection metrics when enabled", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.UpdateCheckerTraceConnections = true
+		svc := provideTestGrafanaService(cfg)
+
+		require.Contains(t, svc.RegisteredMetrics(), "grafana_update_checker_connections_total")
+		require.Contains(t, svc.RegisteredMetrics(), "grafana_update_checker_connection_phase_duration_seconds")
+	})
+}
+
+// tracingHTTPClient reports a connection to the client trace of the request,
+// if it has one.
+type tracingHTTPClient struct {
+	reused bool
+	traced bool
+}
+
+func (c *tracingHTTPClient) Get(url string) (*http.Response, error) {
+	return nil, errors.New("tracing needs Do")
+}
+
+func (c *tracingHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	if trace := httptrace.ContextClientTrace(req.Context()); trace != nil {
+		c.traced = true
+		trace.GotConn(httptrace.GotConnInfo{Reused: c.reused})
+	}
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(`{"stable": "9.3.1"}`)),
+	}, nil
+}
@@ -0,0 +1,97 @@
+package updatechecker
+
+import (
+	"os"
+	"strings"
+)
+
+// Deployment types returned by DeploymentType.
+const (
+	DeploymentContainer = "container"
+	DeploymentPackage   = "package"
+	DeploymentHosted    = "hosted"
+	DeploymentUnknown   = "unknown"
+)
+
+// DeploymentModeManaged marks instances whose upgrades are controlled by their
+// operator, e.g. through a Helm chart with a pinned image tag.
+const DeploymentModeManaged = "managed"
+
+// containerSignals are the hints a process running in a container can be
+// recognized by, abstracted so that tests can fake them.
+type containerSignals struct {
+	lookupEnv func(key string) (string, bool)
+	readFile  func(name string) ([]byte, error)
+}
+
+var hostSignals = containerSignals{
+	lookupEnv: os.LookupEnv,
+	readFile:  os.ReadFile,
+}
+
+// detectDeploymentType tells how this instance was installed, from the
+// packaging it was started with and, for other installs, from hints of a
+// container runtime such as Kubernetes, Docker or containerd.
+func detectDeploymentType(packaging string, signals containerSignals) string {
+	switch packaging {
+	case "docker":
+		return DeploymentContainer
+	case "deb", "rpm", "brew":
+		return DeploymentPackage
+	case "hosted":
+		return DeploymentHosted
+	}
+
+	if _, ok := signals.lookupEnv("KUBERNETES_SERVICE_HOST"); ok {
+		return DeploymentContainer
+	}
+	if _, err := signals.readFile("/.dockerenv"); err == nil {
+		return DeploymentContainer
+	}
+	if cgroup, err := signals.readFile("/proc/1/cgroup"); err == nil {
+		for _, runtime := range []string{"docker", "kubepods", "containerd", "lxc"} {
+			if strings.Contains(string(cgroup), runtime) {
+				return DeploymentContainer
+			}
+		}
+	}
+	return DeploymentUnknown
+}
+
+// ephemeralMarkers are environment variables set by CI systems, whose Grafana
+// instances only live for a test run. The generic CI variable isn't one of
+// them, it's too often left set in production environments.
+var ephemeralMarkers = []string{"GITHUB_ACTIONS", "GITLAB_CI", "BUILDKITE", "JENKINS_URL", "TF_BUILD"}
+
+// detectEphemeral returns the marker telling that this is a short-lived
+// instance, e.g. of a CI job, if there is one. Markers set to false or 0 don't
+// count.
+func detectEphemeral(signals containerSignals) (string, bool) {
+	for _, marker := range ephemeralMarkers {
+		v, ok := signals.lookupEnv(marker)
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "", "false", "0":
+			continue
+		}
+		return marker, true
+	}
+	return "", false
+}
+
+// DeploymentType returns how this instance was installed, one of
+// DeploymentContainer, DeploymentPackage, DeploymentHosted or
+// DeploymentUnknown, so that upgrade advice can be tailored to it. It doesn't
+// affect whether an update is reported.
+func (s *GrafanaService) DeploymentType() string {
+	return s.deploymentType
+}
+
+// BannerSuppressed reports whether the update banner is hidden because the
+// instance runs in managed mode. Checks still run, so UpdateAvailable and the
+// update metrics keep reporting new versions.
+func (s *GrafanaService) BannerSuppressed() bool {
+	return s.bannerSuppressed
+}
@@ -0,0 +1,142 @@
+package updatechecker
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestDetectDeploymentType(t *testing.T) {
+	fakeSignals := func(env map[string]string, files map[string]string) containerSignals {
+		return containerSignals{
+			lookupEnv: func(key string) (string, bool) {
+				v, ok := env[key]
+				return v, ok
+			},
+			readFile: func(name string) ([]byte, error) {
+				content, ok := files[name]
+				if !ok {
+					return nil, os.ErrNotExist
+				}
+				return []byte(content), nil
+			},
+		}
+	}
+	host := fakeSignals(nil, map[string]string{"/proc/1/cgroup": "0::/init.scope\n"})
+
+	tests := []struct {
+		name      string
+		packaging string
+		signals   containerSignals
+		expected  string
+	}{
+		{name: "docker packaging", packaging: "docker", signals: host, expected: DeploymentContainer},
+		{name: "deb packaging", packaging: "deb", signals: host, expected: DeploymentPackage},
+		{name: "rpm packaging", packaging: "rpm", signals: host, expected: DeploymentPackage},
+		{name: "hosted", packaging: "hosted", signals: host, expected: DeploymentHosted},
+		{name: "plain host", packaging: "unknown", signals: host, expected: DeploymentUnknown},
+		{name: "kubernetes", packaging: "unknown", signals: fakeSignals(map[string]string{"KUBERNETES_SERVICE_HOST": "10.0.0.1"}, nil), expected: DeploymentContainer},
+		{name: "docker environment file", packaging: "unknown", signals: fakeSignals(nil, map[string]string{"/.dockerenv": ""}), expected: DeploymentContainer},
+		{name: "container cgroup", packaging: "unknown", signals: fakeSignals(nil, map[string]string{"/proc/1/cgroup": "12:memory:/docker/3f1a\n"}), expected: DeploymentContainer},
+		{name: "packaging wins over container hints", packaging: "deb", signals: fakeSignals(nil, map[string]string{"/.dockerenv": ""}), expected: DeploymentPackage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, detectDeploymentType(tt.packaging, tt.signals))
+		})
+	}
+}
+
+func TestGrafanaUpdateChecker_BannerSuppressed(t *testing.T) {
+	newService := func(mode string) *GrafanaService {
+		cfg := setting.NewCfg()
+		cfg.CheckForGrafanaUpdates = true
+		cfg.BuildVersion = "9.3.0"
+		cfg.DeploymentMode = mode
+		return New(cfg, tracing.InitializeTracerForTest(), WithHTTPClient(&fakeHTTPClient{fakeResp: `{"stable": "9.3.1"}`}))
+	}
+
+	t.Run("managed mode still checks but hides the banner", func(t *testing.T) {
+		svc := newService("managed")
+		require.True(t, svc.BannerSuppressed())
+
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.True(t, svc.UpdateAvailable())
+		require.Equal(t, "9.3.1", svc.LatestVersion())
+		require.Empty(t, svc.BannerMessage())
+	})
+
+	for name, mode := range map[string]string{"default mode": "", "unknown mode": "self-hosted"} {
+		t.Run(name+" shows the banner", func(t *testing.T) {
+			svc := newService(mode)
+			require.False(t, svc.BannerSuppressed())
+
+			require.NoError(t, svc.CheckNow(context.Background()))
+			require.True(t, svc.UpdateAvailable())
+			require.Equal(t, "Grafana 9.3.1 is available. You are running 9.3.0.", svc.BannerMessage())
+		})
+	}
+}
+
+func TestDetectEphemeral(t *testing.T) {
+	envSignals := func(env map[string]string) containerSignals {
+		return containerSignals{lookupEnv: func(key string) (string, bool) {
+			v, ok := env[key]
+			return v, ok
+		}}
+	}
+
+	tests := []struct {
+		name   string
+		env    map[string]string
+		marker string
+	}{
+		{name: "no markers", env: map[string]string{"HOME": "/usr/share/grafana"}},
+		{name: "generic CI", env: map[string]string{"CI": "true"}},
+		{name: "GitLab CI", env: map[string]string{"GITLAB_CI": "true"}, marker: "GITLAB_CI"},
+		{name: "GitHub Actions", env: map[string]string{"GITHUB_ACTIONS": "true"}, marker: "GITHUB_ACTIONS"},
+		{name: "Jenkins", env: map[string]string{"JENKINS_URL": "https://jenkins.example.com/"}, marker: "JENKINS_URL"},
+		{name: "marker set to false", env: map[string]string{"GITHUB_ACTIONS": "false"}},
+		{name: "empty marker", env: map[string]string{"GITHUB_ACTIONS": ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			marker, ok := detectEphemeral(envSignals(tt.env))
+			require.Equal(t, tt.marker != "", ok)
+			require.Equal(t, tt.marker, marker)
+		})
+	}
+}
+
+func TestGrafanaUpdateChecker_ephemeral(t *testing.T) {
+	newService := func(suppress bool, client *fakeHTTPClient) *GrafanaService {
+		cfg := setting.NewCfg()
+		cfg.CheckForGrafanaUpdates = true
+		cfg.BuildVersion = "9.3.0"
+		cfg.UpdateCheckerSuppressInEphemeral = suppress
+		return New(cfg, tracing.InitializeTracerForTest(), WithHTTPClient(client))
+	}
+	t.Setenv("GITHUB_ACTIONS", "true")
+
+	t.Run("ephemeral instances don't check", func(t *testing.T) {
+		client := &fakeHTTPClient{fakeResp: `{"stable": "9.3.1"}`}
+		svc := newService(true, client)
+		require.True(t, svc.IsDisabled())
+		require.ErrorIs(t, svc.CheckNow(context.Background()), ErrChecksDisabled)
+		require.Empty(t, client.requestURL)
+	})
+
+	t.Run("checks when not suppressed", func(t *testing.T) {
+		svc := newService(false, &fakeHTTPClient{fakeResp: `{"stable": "9.3.1"}`})
+		require.False(t, svc.IsDisabled())
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.True(t, svc.UpdateAvailable())
+	})
+}
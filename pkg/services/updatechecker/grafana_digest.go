@@ -0,0 +1,98 @@
+package updatechecker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxDigestSize bounds the response of a digest endpoint, which is expected
+// to hold a single hash.
+const maxDigestSize = 1024
+
+// cachedDigest is the last latest.json of an update server together with the
+// digest its digest endpoint reported for it.
+type cachedDigest struct {
+	digest  string
+	payload fetchedPayload
+}
+
+// latestDigest returns the content hash of latest.json reported by the digest
+// endpoint of url, for mirrors that can't set ETag headers reliably. It's
+// empty when no digest endpoint is configured or it can't be read, in which
+// case latest.json is downloaded in full.
+func (s *GrafanaService) latestDigest(ctx context.Context, url string) string {
+	if s.digestSuffix == "" {
+		return ""
+	}
+
+	digest, err := s.fetchDigest(ctx, url+s.digestSuffix)
+	if err != nil {
+		s.log.Debug("Failed to get latest.json digest, downloading it in full", "url", url+s.digestSuffix, "error", err)
+		return ""
+	}
+	return digest
+}
+
+func (s *GrafanaService) fetchDigest(ctx context.Context, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			s.log.Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxDigestSize))
+	if err != nil {
+		return "", err
+	}
+
+	// Accept the output of sha256sum and friends, which is followed by the
+	// file name.
+	fields := strings.Fields(string(body))
+	if len(fields) == 0 {
+		return "", errors.New("empty digest")
+	}
+	return fields[0], nil
+}
+
+// cachedByDigest returns the cached latest.json of url if digest is the one
+// it was cached with.
+func (s *GrafanaService) cachedByDigest(url, digest string) (fetchedPayload, bool) {
+	if digest == "" {
+		return fetchedPayload{}, false
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	cached, ok := s.digestCache[url]
+	if !ok || cached.digest != digest {
+		return fetchedPayload{}, false
+	}
+	return cached.payload, true
+}
+
+func (s *GrafanaService) cacheDigest(url, digest string, payload fetchedPayload) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.digestCache == nil {
+		s.digestCache = map[string]cachedDigest{}
+	}
+	s.digestCache[url] = cachedDigest{digest: digest, payload: payload}
+}
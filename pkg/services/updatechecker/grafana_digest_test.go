@@ -0,0 +1,71 @@
+package updatechecker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrafanaUpdateChecker_digest(t *testing.T) {
+	const digestURL = defaultLatestJSONURL + ".sha256"
+
+	newService := func(client *routingHTTPClient) *GrafanaService {
+		svc := newTestGrafanaService("9.3.0", client)
+		svc.digestSuffix = ".sha256"
+		return svc
+	}
+
+	t.Run("unchanged digest skips the download", func(t *testing.T) {
+		client := &routingHTTPClient{routes: map[string]routedResponse{
+			digestURL:            {statusCode: http.StatusOK, body: "e3b0c442  latest.json\n"},
+			defaultLatestJSONURL: {statusCode: http.StatusOK, body: `{"stable": "9.3.1"}`},
+		}}
+		svc := newService(client)
+
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.Equal(t, []string{digestURL, defaultLatestJSONURL, digestURL}, client.requested)
+		require.True(t, svc.UpdateAvailable())
+		require.Equal(t, "9.3.1", svc.LatestVersion())
+	})
+
+	t.Run("changed digest downloads latest.json", func(t *testing.T) {
+		client := &routingHTTPClient{routes: map[string]routedResponse{
+			digestURL:            {statusCode: http.StatusOK, body: "e3b0c442"},
+			defaultLatestJSONURL: {statusCode: http.StatusOK, body: `{"stable": "9.3.1"}`},
+		}}
+		svc := newService(client)
+		require.NoError(t, svc.CheckNow(context.Background()))
+
+		client.routes[digestURL] = routedResponse{statusCode: http.StatusOK, body: "98ea6e4f"}
+		client.routes[defaultLatestJSONURL] = routedResponse{statusCode: http.StatusOK, body: `{"stable": "9.4.0"}`}
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.Equal(t, []string{digestURL, defaultLatestJSONURL, digestURL, defaultLatestJSONURL}, client.requested)
+		require.Equal(t, "9.4.0", svc.LatestVersion())
+	})
+
+	t.Run("unavailable digest downloads latest.json", func(t *testing.T) {
+		client := &routingHTTPClient{routes: map[string]routedResponse{
+			digestURL:            {err: errors.New("connection refused")},
+			defaultLatestJSONURL: {statusCode: http.StatusOK, body: `{"stable": "9.3.1"}`},
+		}}
+		svc := newService(client)
+
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.Equal(t, []string{digestURL, defaultLatestJSONURL, digestURL, defaultLatestJSONURL}, client.requested)
+	})
+
+	t.Run("not requested by default", func(t *testing.T) {
+		client := &routingHTTPClient{routes: map[string]routedResponse{
+			defaultLatestJSONURL: {statusCode: http.StatusOK, body: `{"stable": "9.3.1"}`},
+		}}
+		svc := newTestGrafanaService("9.3.0", client)
+
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.Equal(t, []string{defaultLatestJSONURL}, client.requested)
+	})
+}
@@ -0,0 +1,58 @@
+package updatechecker
+
+import "context"
+
+const dismissedVersionKey = "dismissed_version"
+
+// Dismiss hides the update banner for the given version. A newer version
+// brings the banner back, and an empty version undoes the dismissal. The
+// dismissed version is persisted so that the banner doesn't come back after a
+// restart.
+func (s *GrafanaService) Dismiss(version string) {
+	s.mutex.Lock()
+	s.dismissedVersion = version
+	s.mutex.Unlock()
+
+	if s.kvStore == nil {
+		return
+	}
+
+	ctx := context.Background()
+	var err error
+	if version == "" {
+		err = s.kvStore.Del(ctx, dismissedVersionKey)
+	} else {
+		err = s.kvStore.Set(ctx, dismissedVersionKey, version)
+	}
+	if err != nil {
+		s.log.Warn("Failed to persist update banner dismissal", "version", version, "error", err)
+	}
+}
+
+// isDismissed reports whether the available update has been dismissed.
+func (s *GrafanaService) isDismissed() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.hasUpdate && s.dismissedVersion != "" && s.dismissedVersion == s.latestVersion
+}
+
+// loadDismissal restores a dismissal persisted before the last restart.
+func (s *GrafanaService) loadDismissal(ctx context.Context) {
+	if s.kvStore == nil {
+		return
+	}
+
+	version, ok, err := s.kvStore.Get(ctx, dismissedVersionKey)
+	if err != nil {
+		s.log.Warn("Failed to load update banner dismissal", "error", err)
+		return
+	}
+	if !ok {
+		return
+	}
+
+	s.mutex.Lock()
+	s.dismissedVersion = version
+	s.mutex.Unlock()
+}
@@ -0,0 +1,72 @@
+package updatechecker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+)
+
+func TestGrafanaUpdateChecker_Dismiss(t *testing.T) {
+	t.Run("hides the banner of the dismissed version", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"stable": "9.4.0"}`})
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.NotEmpty(t, svc.BannerMessage())
+
+		svc.Dismiss("9.4.0")
+		require.Empty(t, svc.BannerMessage())
+		require.Equal(t, NoUpdateReasonDismissed, svc.NoUpdateReason())
+		require.True(t, svc.UpdateAvailable())
+
+		svc.Dismiss("")
+		require.NotEmpty(t, svc.BannerMessage())
+		require.Empty(t, svc.NoUpdateReason())
+	})
+
+	t.Run("dismissing another version keeps the banner", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"stable": "9.4.0"}`})
+		require.NoError(t, svc.CheckNow(context.Background()))
+
+		svc.Dismiss("9.3.5")
+		require.NotEmpty(t, svc.BannerMessage())
+	})
+
+	t.Run("a newer version brings the banner back", func(t *testing.T) {
+		client := &fakeHTTPClient{fakeResp: `{"stable": "9.4.0"}`}
+		svc := newTestGrafanaService("9.3.0", client)
+		require.NoError(t, svc.CheckNow(context.Background()))
+		svc.Dismiss("9.4.0")
+
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.Empty(t, svc.BannerMessage())
+
+		client.fakeResp = `{"stable": "9.4.1"}`
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.NotEmpty(t, svc.BannerMessage())
+		require.Empty(t, svc.NoUpdateReason())
+	})
+
+	t.Run("dismissal is persisted across restarts", func(t *testing.T) {
+		kv := kvstore.NewFakeKVStore()
+
+		before := newTestGrafanaService("9.3.0", &scriptedHTTPClient{})
+		before.kvStore = kvstore.WithNamespace(kv, 0, "updatechecker.grafana")
+		before.Dismiss("9.4.0")
+
+		h := newRunHarness(t, "9.3.0", scriptedResponse{body: `{"stable": "9.4.0"}`})
+		h.svc.kvStore = kvstore.WithNamespace(kv, 0, "updatechecker.grafana")
+		h.start()
+		h.requireState("9.4.0", true)
+		require.Empty(t, h.svc.BannerMessage())
+		require.ErrorIs(t, h.stop(), context.Canceled)
+
+		before.Dismiss("")
+		h = newRunHarness(t, "9.3.0", scriptedResponse{body: `{"stable": "9.4.0"}`})
+		h.svc.kvStore = kvstore.WithNamespace(kv, 0, "updatechecker.grafana")
+		h.start()
+		require.NotEmpty(t, h.svc.BannerMessage())
+		require.ErrorIs(t, h.stop(), context.Canceled)
+	})
+}
@@ -0,0 +1,88 @@
+package updatechecker
+
+import (
+	"time"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// UpdateCheckerConfig is the configuration the update checker runs with, after
+// defaults, fallbacks for invalid values and runtime changes are applied.
+type UpdateCheckerConfig struct {
+	Enabled           bool            `json:"enabled"`
+	Interval          time.Duration   `json:"interval"`
+	URLs              []string        `json:"urls"`
+	Method            string          `json:"method"`
+	PayloadKey        string          `json:"payloadKey"`
+	Region            string          `json:"region"`
+	Channel           string          `json:"channel"`
+	DeploymentChannel string          `json:"deploymentChannel"`
+	Comparison        string          `json:"comparison"`
+	Timeouts          RequestTimeouts `json:"timeouts"`
+	IgnoreTesting     bool            `json:"ignoreTesting"`
+	ChannelLadder     bool            `json:"channelLadder"`
+	NotifyAboutBetas  bool            `json:"notifyAboutBetas"`
+	RebuildsAsUpdates bool            `json:"rebuildsAsUpdates"`
+	SendInstanceID    bool            `json:"sendInstanceId"`
+	BannerSuppressed  bool            `json:"bannerSuppressed"`
+	WebhookURL        string          `json:"webhookUrl"`
+	WebhookSecret     string          `json:"webhookSecret"`
+}
+
+// RequestTimeouts are the timeouts of requests to the update servers.
+type RequestTimeouts struct {
+	Total          time.Duration `json:"total"`
+	Dial           time.Duration `json:"dial"`
+	TLSHandshake   time.Duration `json:"tlsHandshake"`
+	ResponseHeader time.Duration `json:"responseHeader"`
+}
+
+func requestTimeouts(cfg *setting.Cfg) RequestTimeouts {
+	return RequestTimeouts{
+		Total:          cfg.UpdateCheckerTimeout,
+		Dial:           cfg.UpdateCheckerDialTimeout,
+		TLSHandshake:   cfg.UpdateCheckerTLSHandshakeTimeout,
+		ResponseHeader: cfg.UpdateCheckerResponseHeaderTimeout,
+	}
+}
+
+// EffectiveConfig returns a snapshot of the configuration the update checker
+// runs with, e.g. for diagnostics. Credentials in URLs and the webhook secret
+// are redacted.
+func (s *GrafanaService) EffectiveConfig() UpdateCheckerConfig {
+	channel := s.releaseChannel()
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	urls := make([]string, len(s.mirrors))
+	for i, m := range s.mirrors {
+		urls[i] = redactURL(m.URL)
+	}
+
+	config := UpdateCheckerConfig{
+		Enabled:           s.enabled,
+		Interval:          s.interval,
+		URLs:              urls,
+		Method:            s.method,
+		PayloadKey:        s.payloadKey,
+		Region:            s.region,
+		Channel:           channel,
+		DeploymentChannel: s.deploymentChannel,
+		Comparison:        s.comparison,
+		Timeouts:          s.timeouts,
+		IgnoreTesting:     s.ignoreTesting,
+		ChannelLadder:     s.channelLadder,
+		NotifyAboutBetas:  s.notifyAboutBetas,
+		RebuildsAsUpdates: s.rebuildsAsUpdates,
+		SendInstanceID:    s.sendInstanceID,
+		BannerSuppressed:  s.bannerSuppressed,
+	}
+	if s.webhook != nil {
+		config.WebhookURL = redactURL(s.webhook.url)
+		if s.webhook.secret != "" {
+			config.WebhookSecret = setting.RedactedPassword
+		}
+	}
+	return config
+}
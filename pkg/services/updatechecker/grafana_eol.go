@@ -0,0 +1,23 @@
+package updatechecker
+
+import "time"
+
+// TimeToEndOfLife returns how long until the release line of the running
+// version reaches its end of life, which is negative once it has. Within the
+// clock skew tolerance past it, the line is taken to reach it now. It returns
+// false when the update server doesn't say when the line reaches it, or the
+// local clock isn't set.
+func (s *GrafanaService) TimeToEndOfLife() (time.Duration, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	now := s.clock.Now()
+	if s.endOfLife.IsZero() || s.clockUnset(now) {
+		return 0, false
+	}
+	d := s.endOfLife.Sub(now)
+	if d < 0 && -d <= s.clockSkewTolerance {
+		d = 0
+	}
+	return d, true
+}
@@ -0,0 +1,84 @@
+package updatechecker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrafanaUpdateChecker_TimeToEndOfLife(t *testing.T) {
+	now := time.Date(2023, time.March, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		resp      string
+		tolerance time.Duration
+		expected  time.Duration
+		ok        bool
+	}{
+		{
+			name:     "future end of life",
+			resp:     `{"stable": "9.4.3", "eolDates": {"9.x": "2023-06-01"}}`,
+			expected: 92 * 24 * time.Hour,
+			ok:       true,
+		},
+		{
+			name:     "past end of life",
+			resp:     `{"stable": "9.4.3", "eolDates": {"9.x": "2023-02-28T12:00:00Z"}}`,
+			expected: -12 * time.Hour,
+			ok:       true,
+		},
+		{
+			name:      "within the clock skew tolerance",
+			resp:      `{"stable": "9.4.3", "eolDates": {"9.x": "2023-02-28T23:55:00Z"}}`,
+			tolerance: 5 * time.Minute,
+			expected:  0,
+			ok:        true,
+		},
+		{
+			name:      "beyond the clock skew tolerance",
+			resp:      `{"stable": "9.4.3", "eolDates": {"9.x": "2023-02-28T23:54:59Z"}}`,
+			tolerance: 5 * time.Minute,
+			expected:  -5*time.Minute - time.Second,
+			ok:        true,
+		},
+		{
+			name:     "minor line takes precedence",
+			resp:     `{"stable": "9.4.3", "eolDates": {"9.x": "2023-06-01", "9.3.x": "2023-03-02"}}`,
+			expected: 24 * time.Hour,
+			ok:       true,
+		},
+		{
+			name: "no end of life data",
+			resp: `{"stable": "9.4.3"}`,
+		},
+		{
+			name: "no data for the running line",
+			resp: `{"stable": "9.4.3", "eolDates": {"8.x": "2022-06-01"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: tt.resp})
+			svc.clockSkewTolerance = tt.tolerance
+			svc.clock.(*clock.Mock).Set(now)
+			require.NoError(t, svc.CheckNow(context.Background()))
+
+			d, ok := svc.TimeToEndOfLife()
+			require.Equal(t, tt.ok, ok)
+			require.Equal(t, tt.expected, d)
+		})
+	}
+
+	t.Run("malformed date fails the check", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"stable": "9.4.3", "eolDates": {"9.x": "soon"}}`})
+		svc.clock.(*clock.Mock).Set(now)
+		require.ErrorContains(t, svc.CheckNow(context.Background()), `invalid end of life date "soon"`)
+
+		_, ok := svc.TimeToEndOfLife()
+		require.False(t, ok)
+	})
+}
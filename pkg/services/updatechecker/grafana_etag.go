@@ -0,0 +1,30 @@
+package updatechecker
+
+// cachedResponse is the last response of an update server that came with an
+// ETag, which is sent back in If-None-Match to skip unchanged downloads.
+type cachedResponse struct {
+	etag    string
+	payload fetchedPayload
+}
+
+func (s *GrafanaService) cachedResponse(url string) (cachedResponse, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	cached, ok := s.etagCache[url]
+	return cached, ok
+}
+
+func (s *GrafanaService) cacheResponse(url, etag string, payload fetchedPayload) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if etag == "" {
+		delete(s.etagCache, url)
+		return
+	}
+	if s.etagCache == nil {
+		s.etagCache = map[string]cachedResponse{}
+	}
+	s.etagCache[url] = cachedResponse{etag: etag, payload: payload}
+}
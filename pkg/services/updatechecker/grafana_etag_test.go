@@ -0,0 +1,48 @@
+package updatechecker
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrafanaUpdateChecker_etag(t *testing.T) {
+	t.Run("not modified keeps the status", func(t *testing.T) {
+		client := &etagHTTPClient{etag: `"v1"`, body: `{"stable": "9.4.0", "recommended": "9.3.6", "security": true}`}
+		svc := newTestGrafanaService("9.3.0", client)
+		require.NoError(t, svc.CheckNow(context.Background()))
+		before := svc.Status()
+
+		svc.clock.(*clock.Mock).Add(time.Hour)
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.Equal(t, `"v1"`, client.ifNoneMatch)
+
+		after := svc.Status()
+		require.Equal(t, UpdateStatus{
+			CurrentVersion:     "9.3.0",
+			LatestVersion:      "9.4.0",
+			RecommendedVersion: "9.3.6",
+			UpdateAvailable:    true,
+			SecurityUpdate:     true,
+			CheckedAt:          before.CheckedAt.Add(time.Hour),
+		}, after)
+	})
+
+	t.Run("not modified without a cached response fails", func(t *testing.T) {
+		client := &routingHTTPClient{routes: map[string]routedResponse{
+			defaultLatestJSONURL: {statusCode: http.StatusOK, body: `{"stable": "9.4.0"}`},
+		}}
+		svc := newTestGrafanaService("9.3.0", client)
+		require.NoError(t, svc.CheckNow(context.Background()))
+		before := svc.Status()
+
+		client.routes[defaultLatestJSONURL] = routedResponse{statusCode: http.StatusNotModified}
+		svc.clock.(*clock.Mock).Add(time.Hour)
+		require.ErrorContains(t, svc.CheckNow(context.Background()), "unexpected status code 304")
+		require.Equal(t, before, svc.Status())
+	})
+}
@@ -0,0 +1,61 @@
+package updatechecker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// FuzzGrafanaUpdateChecker_checkForUpdates feeds arbitrary update server
+// responses through parsing and comparison. A check must never panic, and must
+// either fail without touching the update status or leave a valid one behind.
+func FuzzGrafanaUpdateChecker_checkForUpdates(f *testing.F) {
+	for _, seed := range []string{
+		// the latest.json served from the repository
+		`{"stable": "9.4.3", "testing": "9.4.3"}`,
+		`{"stable": "9.4.3", "testing": "10.0.0-beta1", "security": true, "recommended": "9.4.3", "releaseDates": {"9.4.3": "2023-03-02"}}`,
+		`{"latestStable": "9.4.3", "latestTesting": "10.0.0-beta1"}`,
+		`{"schemaVersion": 2, "channels": {"stable": {"version": "9.4.3", "releasedAt": "2023-03-02", "security": true}, "testing": {"version": "10.0.0-beta1"}}}`,
+		`{"releases": ["9.4.2", "9.4.3"], "next": "https://example.com/latest.json?page=2"}`,
+		`{"stable": "9.4.3", "platforms": {"linux": {"stable": "9.4.2"}}, "builds": {"9.4.3": {"commit": "abc123"}}}`,
+		`{"stable": "9.4.3", "vulnerable": [{"cve": "CVE-2023-0001", "versions": "< 9.4.3"}], "eol": ["8.x"], "supported": ["9.x"]}`,
+		// malformed payloads
+		``,
+		`null`,
+		`[]`,
+		`not json`,
+		`{"stable": "9.4`,
+		`{"stable": ""}`,
+		`{"stable": "latest"}`,
+		`{"stable": 9.4}`,
+		`{"stable": "99999999999999999999.0.0"}`,
+		`{"schemaVersion": 2, "channels": null}`,
+		`{"schemaVersion": 99, "stable": "9.4.3"}`,
+		`{"stable": "9.4.3", "releaseDates": {"9.4.3": "yesterday"}}`,
+	} {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		client := &fakeHTTPClient{fakeResp: `{"stable": "9.3.1"}`}
+		svc := newTestGrafanaService("9.3.0", client)
+		_, err := svc.checkForUpdates(context.Background())
+		require.NoError(t, err)
+		before := svc.Status()
+
+		client.fakeResp = string(body)
+		if _, err := svc.checkForUpdates(context.Background()); err != nil {
+			require.Equal(t, before, svc.Status())
+			return
+		}
+
+		status := svc.Status()
+		if status.UpdateAvailable {
+			require.NotEmpty(t, status.LatestVersion)
+		}
+		if status.LatestVersion != "" {
+			require.NoError(t, validateVersion("latest", status.LatestVersion))
+		}
+	})
+}
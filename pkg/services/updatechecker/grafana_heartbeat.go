@@ -0,0 +1,30 @@
+package updatechecker
+
+import (
+	"time"
+)
+
+// heartbeatTicker returns the channel on which the Run loop logs the update
+// status, and a function stopping it. The channel is nil when the heartbeat is
+// disabled.
+func (s *GrafanaService) heartbeatTicker() (<-chan time.Time, func()) {
+	if s.heartbeatInterval <= 0 {
+		return nil, func() {}
+	}
+	ticker := s.clock.Ticker(s.heartbeatInterval)
+	return ticker.C, ticker.Stop
+}
+
+// logHeartbeat logs a summary of the status of the last check, for operators
+// ingesting logs rather than metrics. It doesn't trigger a check.
+func (s *GrafanaService) logHeartbeat() {
+	status := s.Status()
+	s.log.Info("Grafana update status",
+		"currentVersion", status.CurrentVersion,
+		"latestVersion", status.LatestVersion,
+		"updateAvailable", status.UpdateAvailable,
+		"securityUpdate", status.SecurityUpdate,
+		"checkedAt", status.CheckedAt,
+		"noUpdateReason", s.NoUpdateReason(),
+	)
+}
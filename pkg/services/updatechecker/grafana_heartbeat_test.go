@@ -0,0 +1,73 @@
+package updatechecker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+func TestGrafanaUpdateChecker_heartbeat(t *testing.T) {
+	script := make([]scriptedResponse, 0, 6)
+	for i := 0; i < cap(script); i++ {
+		script = append(script, scriptedResponse{body: `{"stable": "9.3.1"}`})
+	}
+	h := newRunHarness(t, "9.3.0", script...)
+	logger := &heartbeatLogger{Logger: log.NewNopLogger(), heartbeats: make(chan []interface{}, 2)}
+	h.svc.log = logger
+	h.svc.heartbeatInterval = 25 * time.Minute
+
+	h.start()
+	h.tick()
+	h.tick()
+	require.Empty(t, logger.heartbeats)
+
+	// The heartbeat at 25 minutes falls between the checks at 20 and 30.
+	h.tick()
+	heartbeat := logger.next(t)
+	require.Contains(t, heartbeat, "latestVersion")
+	require.Contains(t, heartbeat, "9.3.1")
+	require.Contains(t, heartbeat, "updateAvailable")
+	require.Equal(t, 4, h.client.requestCount())
+
+	h.tick()
+	h.tick()
+	logger.next(t)
+	require.Empty(t, logger.heartbeats)
+	require.Equal(t, 6, h.client.requestCount())
+	require.ErrorIs(t, h.stop(), context.Canceled)
+}
+
+func TestGrafanaUpdateChecker_heartbeatDisabled(t *testing.T) {
+	heartbeat, stop := newTestGrafanaService("9.3.0", &fakeHTTPClient{}).heartbeatTicker()
+	defer stop()
+	require.Nil(t, heartbeat)
+}
+
+// heartbeatLogger passes on the context of heartbeat logs, which the Run loop
+// writes from another goroutine.
+type heartbeatLogger struct {
+	log.Logger
+	heartbeats chan []interface{}
+}
+
+func (l *heartbeatLogger) Info(msg string, ctx ...interface{}) {
+	if msg == "Grafana update status" {
+		l.heartbeats <- ctx
+	}
+}
+
+func (l *heartbeatLogger) next(t *testing.T) []interface{} {
+	t.Helper()
+
+	select {
+	case ctx := <-l.heartbeats:
+		return ctx
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the heartbeat")
+		return nil
+	}
+}
@@ -0,0 +1,88 @@
+package updatechecker
+
+import (
+	"time"
+)
+
+// checkHistorySize bounds the number of check outcomes kept by History.
+const checkHistorySize = 50
+
+// CheckRecord is the outcome of a single update check.
+type CheckRecord struct {
+	At             time.Time     `json:"at"`
+	Success        bool          `json:"success"`
+	Error          string        `json:"error,omitempty"`
+	CurrentVersion string        `json:"currentVersion"`
+	LatestVersion  string        `json:"latestVersion"`
+	Duration       time.Duration `json:"duration"`
+}
+
+// checkHistory is a ring buffer of the most recent check outcomes.
+type checkHistory struct {
+	records [checkHistorySize]CheckRecord
+	next    int
+}
+
+func (h *checkHistory) add(r CheckRecord) {
+	h.records[h.next%checkHistorySize] = r
+	h.next++
+}
+
+// list returns the records, oldest first.
+func (h *checkHistory) list() []CheckRecord {
+	n := h.next
+	if n > checkHistorySize {
+		n = checkHistorySize
+	}
+
+	list := make([]CheckRecord, 0, n)
+	for i := h.next - n; i < h.next; i++ {
+		list = append(list, h.records[i%checkHistorySize])
+	}
+	return list
+}
+
+// History returns the outcomes of the most recent update checks, oldest
+// first, e.g. to debug flapping egress.
+func (s *GrafanaService) History() []CheckRecord {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.history.list()
+}
+
+func (s *GrafanaService) recordHistory(start time.Time, err error) CheckRecord {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	r := CheckRecord{
+		At:             start,
+		Success:        err == nil,
+		CurrentVersion: s.grafanaVersion,
+		LatestVersion:  s.latestVersion,
+		Duration:       s.clock.Since(start),
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	s.history.add(r)
+	return r
+}
+
+// OnCheckComplete registers fn to be called with the outcome of every check,
+// whether it succeeded or not. Callbacks are called without holding the
+// mutex, so they may call back into the service.
+func (s *GrafanaService) OnCheckComplete(fn func(result CheckRecord)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.onCheckComplete = append(s.onCheckComplete, fn)
+}
+
+func (s *GrafanaService) checkCompleted(r CheckRecord) {
+	s.mutex.RLock()
+	callbacks := s.onCheckComplete
+	s.mutex.RUnlock()
+
+	for _, fn := range callbacks {
+		fn(r)
+	}
+}
@@ -0,0 +1,80 @@
+package updatechecker
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrafanaUpdateChecker_History(t *testing.T) {
+	t.Run("empty before any check", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{})
+		require.Empty(t, svc.History())
+	})
+
+	t.Run("records successes and failures", func(t *testing.T) {
+		client := &fakeHTTPClient{fakeResp: `{"stable": "9.3.1"}`}
+		svc := newTestGrafanaService("9.3.0", client)
+		mock := svc.clock.(*clock.Mock)
+
+		require.NoError(t, svc.instrumentedCheckForUpdates(context.Background()))
+		mock.Add(time.Minute)
+		client.fakeResp = `not json`
+		require.Error(t, svc.instrumentedCheckForUpdates(context.Background()))
+
+		history := svc.History()
+		require.Len(t, history, 2)
+		require.Equal(t, CheckRecord{At: mock.Now().Add(-time.Minute), Success: true, CurrentVersion: "9.3.0", LatestVersion: "9.3.1"}, history[0])
+		require.False(t, history[1].Success)
+		require.Contains(t, history[1].Error, "failed to unmarshal latest.json")
+		require.Equal(t, mock.Now(), history[1].At)
+	})
+
+	t.Run("is capped and ordered most recent last", func(t *testing.T) {
+		client := &fakeHTTPClient{}
+		svc := newTestGrafanaService("9.3.0", client)
+		mock := svc.clock.(*clock.Mock)
+
+		const checks = checkHistorySize + 10
+		for i := 1; i <= checks; i++ {
+			client.fakeResp = fmt.Sprintf(`{"stable": "9.3.%d"}`, i)
+			require.NoError(t, svc.instrumentedCheckForUpdates(context.Background()))
+			mock.Add(time.Minute)
+		}
+
+		history := svc.History()
+		require.Len(t, history, checkHistorySize)
+		require.Equal(t, "9.3.11", history[0].LatestVersion)
+		require.Equal(t, fmt.Sprintf("9.3.%d", checks), history[len(history)-1].LatestVersion)
+		for i := 1; i < len(history); i++ {
+			require.True(t, history[i-1].At.Before(history[i].At))
+		}
+	})
+}
+
+func TestGrafanaUpdateChecker_OnCheckComplete(t *testing.T) {
+	client := &fakeHTTPClient{fakeResp: `{"stable": "9.3.1"}`}
+	svc := newTestGrafanaService("9.3.0", client)
+
+	var results []CheckRecord
+	svc.OnCheckComplete(func(result CheckRecord) {
+		// the mutex isn't held, so reading the state doesn't deadlock
+		require.Equal(t, result.LatestVersion, svc.LatestVersion())
+		results = append(results, result)
+	})
+
+	require.NoError(t, svc.instrumentedCheckForUpdates(context.Background()))
+	client.fakeResp = `not json`
+	require.Error(t, svc.instrumentedCheckForUpdates(context.Background()))
+
+	require.Len(t, results, 2)
+	require.True(t, results[0].Success)
+	require.Equal(t, "9.3.1", results[0].LatestVersion)
+	require.False(t, results[1].Success)
+	require.Contains(t, results[1].Error, "failed to unmarshal latest.json")
+	require.Equal(t, svc.History(), results)
+}
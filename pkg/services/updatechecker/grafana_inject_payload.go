@@ -0,0 +1,37 @@
+package updatechecker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+)
+
+// injectedPayloadURL stands in for the URL of injected payloads.
+const injectedPayloadURL = "injected"
+
+// ErrTestingOverridesDisabled is returned by testing helpers outside of
+// development mode.
+var ErrTestingOverridesDisabled = errors.New("testing overrides are only available in development mode")
+
+// InjectPayload updates the checker from raw as if an update server had
+// served it, e.g. for integration tests of components depending on the
+// checker. It only works in development mode and leaves the checker untouched
+// otherwise.
+func (s *GrafanaService) InjectPayload(raw []byte) error {
+	if !s.testingOverrides {
+		s.log.Warn("Ignoring injected latest.json outside of development mode")
+		return ErrTestingOverridesDisabled
+	}
+
+	ctx, span := s.tracer.Start(context.Background(), "updatechecker InjectPayload")
+	defer span.End()
+
+	s.refreshVersion()
+	payload := decodePayload(bytes.NewReader(raw), s.payloadKey)
+	if _, err := s.applyPayload(ctx, span, payload, injectedPayloadURL, http.StatusOK); err != nil {
+		return err
+	}
+	s.metrics.updateAvailable.Set(boolToFloat64(s.UpdateAvailable()))
+	return nil
+}
@@ -0,0 +1,52 @@
+package updatechecker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrafanaUpdateChecker_InjectPayload(t *testing.T) {
+	payloads := []string{
+		`{"stable": "9.3.1", "testing": "9.4.0-beta1"}`,
+		`{"stable": "9.3.2", "security": true, "recommended": "9.3.1", "supported": ["9.x"]}`,
+		`{"stable": "9.3.0"}`,
+	}
+
+	t.Run("updates the state like a real fetch", func(t *testing.T) {
+		for _, payload := range payloads {
+			fetched := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: payload})
+			require.NoError(t, fetched.CheckNow(context.Background()))
+
+			injected := newTestGrafanaService("9.3.0", &fakeHTTPClient{})
+			injected.testingOverrides = true
+			require.NoError(t, injected.InjectPayload([]byte(payload)))
+
+			require.Equal(t, fetched.Status(), injected.Status(), payload)
+			require.Equal(t, fetched.UpdateSeverity(), injected.UpdateSeverity(), payload)
+			require.Equal(t, fetched.SupportedVersionLines(), injected.SupportedVersionLines(), payload)
+			require.Equal(t, testutil.ToFloat64(fetched.metrics.updateAvailable), testutil.ToFloat64(injected.metrics.updateAvailable), payload)
+		}
+	})
+
+	t.Run("rejects invalid payloads", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{})
+		svc.testingOverrides = true
+		require.NoError(t, svc.InjectPayload([]byte(`{"stable": "9.3.1"}`)))
+
+		require.Error(t, svc.InjectPayload([]byte(`not json`)))
+		require.Equal(t, "9.3.1", svc.LatestVersion())
+	})
+
+	t.Run("is a no-op outside of development mode", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"stable": "9.3.0"}`})
+		require.NoError(t, svc.CheckNow(context.Background()))
+		before := svc.Status()
+
+		require.ErrorIs(t, svc.InjectPayload([]byte(`{"stable": "9.4.0"}`)), ErrTestingOverridesDisabled)
+		require.Equal(t, before, svc.Status())
+		require.False(t, svc.UpdateAvailable())
+	})
+}
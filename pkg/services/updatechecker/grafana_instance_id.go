@@ -0,0 +1,47 @@
+package updatechecker
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const (
+	instanceIDHeader = "X-Grafana-Instance-Id"
+	instanceIDKey    = "instance_id"
+)
+
+// instanceID returns the identifier sent to update servers when sending it is
+// enabled. It's a random ID of its own, created on first use and persisted,
+// so that it's stable but can't be correlated with the anonymous ID reported
+// by usage stats.
+func (s *GrafanaService) instanceID(ctx context.Context) string {
+	if !s.sendInstanceID || s.kvStore == nil {
+		return ""
+	}
+
+	s.mutex.RLock()
+	id := s.cachedInstanceID
+	s.mutex.RUnlock()
+	if id != "" {
+		return id
+	}
+
+	id, ok, err := s.kvStore.Get(ctx, instanceIDKey)
+	if err != nil {
+		s.log.Warn("Failed to read the update check instance ID, not sending it", "error", err)
+		return ""
+	}
+	if !ok {
+		id = uuid.NewString()
+		if err := s.kvStore.Set(ctx, instanceIDKey, id); err != nil {
+			s.log.Warn("Failed to store the update check instance ID, not sending it", "error", err)
+			return ""
+		}
+	}
+
+	s.mutex.Lock()
+	s.cachedInstanceID = id
+	s.mutex.Unlock()
+	return id
+}
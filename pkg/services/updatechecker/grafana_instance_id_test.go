@@ -0,0 +1,64 @@
+package updatechecker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+)
+
+func TestGrafanaUpdateChecker_instanceID(t *testing.T) {
+	newService := func(send bool, kv kvstore.KVStore) (*GrafanaService, *fakeHTTPClient) {
+		client := &fakeHTTPClient{fakeResp: `{"stable": "9.3.1"}`}
+		svc := newTestGrafanaService("9.3.0", client)
+		svc.sendInstanceID = send
+		svc.kvStore = kvstore.WithNamespace(kv, 0, "updatechecker.grafana")
+		return svc, client
+	}
+
+	t.Run("not sent by default", func(t *testing.T) {
+		svc, client := newService(false, kvstore.NewFakeKVStore())
+
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.Empty(t, client.requestHeader.Get(instanceIDHeader))
+	})
+
+	t.Run("sends a stable ID when enabled", func(t *testing.T) {
+		kv := kvstore.NewFakeKVStore()
+		svc, client := newService(true, kv)
+
+		require.NoError(t, svc.CheckNow(context.Background()))
+		sent := client.requestHeader.Get(instanceIDHeader)
+		require.NotEmpty(t, sent)
+
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.Equal(t, sent, client.requestHeader.Get(instanceIDHeader))
+
+		restarted, client := newService(true, kv)
+		require.NoError(t, restarted.CheckNow(context.Background()))
+		require.Equal(t, sent, client.requestHeader.Get(instanceIDHeader))
+	})
+
+	t.Run("isn't derived from the usage stats anonymous ID", func(t *testing.T) {
+		kv := kvstore.NewFakeKVStore()
+		usageStats := kvstore.WithNamespace(kv, 0, "infra.usagestats")
+		require.NoError(t, usageStats.Set(context.Background(), "anonymous_id", "4d5e6f"))
+		svc, client := newService(true, kv)
+
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.NotEmpty(t, client.requestHeader.Get(instanceIDHeader))
+		require.NotContains(t, client.requestHeader.Get(instanceIDHeader), "4d5e6f")
+	})
+
+	t.Run("doesn't create a usage stats anonymous ID", func(t *testing.T) {
+		kv := kvstore.NewFakeKVStore()
+		svc, _ := newService(true, kv)
+
+		require.NoError(t, svc.CheckNow(context.Background()))
+		_, ok, err := kvstore.WithNamespace(kv, 0, "infra.usagestats").Get(context.Background(), "anonymous_id")
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+}
@@ -0,0 +1,47 @@
+package updatechecker
+
+import (
+	"fmt"
+)
+
+// invariantViolations returns the inconsistencies between the state after a
+// check and the payload and release channel the check used. Any violation is
+// a bug in the checker.
+func (s *GrafanaService) invariantViolations(latest latestJSON, channel string) []string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var violations []string
+	expected := latest.Stable
+	if channel == channelTesting {
+		expected = latest.Testing
+	}
+	if s.latestVersion != expected {
+		violations = append(violations, fmt.Sprintf("latest version %q isn't the %s version %q", s.latestVersion, channel, expected))
+	}
+	if s.hasUpdate && s.latestVersion == "" {
+		violations = append(violations, "an update is available without a latest version")
+	}
+	if s.hasUpdate && s.comparison != ComparisonServer && s.parsedGrafanaVersion != nil && s.parsedLatestVersion != nil &&
+		s.parsedLatestVersion.LessThan(s.parsedGrafanaVersion) {
+		violations = append(violations, fmt.Sprintf("an update is available although latest version %s is older than %s", s.latestVersion, s.grafanaVersion))
+	}
+	if s.hasBetaUpdate && s.previewVersion == "" {
+		violations = append(violations, "a beta update is available without a preview version")
+	}
+	if s.requiresMigration && !s.hasUpdate {
+		violations = append(violations, "a migration is required without an update")
+	}
+	return violations
+}
+
+// checkInvariants logs violations of the invariants in development mode, so
+// that regressions in the interplay of features stand out.
+func (s *GrafanaService) checkInvariants(latest latestJSON, channel string) {
+	if !s.assertInvariants {
+		return
+	}
+	for _, violation := range s.invariantViolations(latest, channel) {
+		s.log.Error("Update checker invariant violated", "violation", violation)
+	}
+}
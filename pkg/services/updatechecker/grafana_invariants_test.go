@@ -0,0 +1,65 @@
+package updatechecker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log/logtest"
+)
+
+func TestGrafanaUpdateChecker_invariants(t *testing.T) {
+	t.Run("hold after checks", func(t *testing.T) {
+		logger := &logtest.Fake{}
+		client := &fakeHTTPClient{fakeResp: `{"stable": "9.3.1", "testing": "9.4.0-beta1"}`}
+		svc := newTestGrafanaService("9.3.0", client)
+		svc.log = logger
+		svc.assertInvariants = true
+		svc.notifyAboutBetas = true
+
+		require.NoError(t, svc.CheckNow(context.Background()))
+		client.fakeResp = `{"stable": "9.3.0"}`
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.Zero(t, logger.ErrorLogs.Calls)
+	})
+
+	t.Run("flag an inconsistent state", func(t *testing.T) {
+		logger := &logtest.Fake{}
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"stable": "9.2.0"}`})
+		svc.log = logger
+		svc.assertInvariants = true
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.Zero(t, logger.ErrorLogs.Calls)
+
+		svc.mutex.Lock()
+		svc.hasUpdate = true
+		svc.hasBetaUpdate = true
+		svc.mutex.Unlock()
+
+		latest := latestJSON{Stable: "9.2.0", Testing: "9.4.0-beta1"}
+		require.Equal(t, []string{
+			"an update is available although latest version 9.2.0 is older than 9.3.0",
+			"a beta update is available without a preview version",
+		}, svc.invariantViolations(latest, channelStable))
+		require.Equal(t, []string{
+			`latest version "9.2.0" isn't the testing version "9.4.0-beta1"`,
+			"an update is available although latest version 9.2.0 is older than 9.3.0",
+			"a beta update is available without a preview version",
+		}, svc.invariantViolations(latest, channelTesting))
+
+		svc.checkInvariants(latest, channelStable)
+		require.Equal(t, 2, logger.ErrorLogs.Calls)
+		require.Equal(t, "Update checker invariant violated", logger.ErrorLogs.Message)
+	})
+
+	t.Run("aren't checked outside of development mode", func(t *testing.T) {
+		logger := &logtest.Fake{}
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"stable": "9.2.0"}`})
+		svc.log = logger
+		svc.hasUpdate = true
+
+		svc.checkInvariants(latestJSON{Stable: "9.2.0"}, channelStable)
+		require.Zero(t, logger.ErrorLogs.Calls)
+	})
+}
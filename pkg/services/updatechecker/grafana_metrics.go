@@ -0,0 +1,211 @@
+package updatechecker
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+
+	"github.com/grafana/grafana/pkg/infra/tracing"
+)
+
+const (
+	metricsNamespace = "grafana"
+	metricsSubsystem = "update_checker"
+)
+
+type grafanaMetrics struct {
+	checks          *prometheus.CounterVec
+	checkDuration   prometheus.Histogram
+	updateAvailable prometheus.Gauge
+	lastSuccess     prometheus.Gauge
+	receivedBytes   *prometheus.CounterVec
+	enabled         prometheus.Gauge
+
+	// connections and connectionPhases are only set when connection tracing
+	// is enabled.
+	connections      *prometheus.CounterVec
+	connectionPhases *prometheus.HistogramVec
+
+	constLabels prometheus.Labels
+
+	// registered holds the fully qualified names of the collectors that were
+	// registered, registrationErrors the ones that could not be.
+	registered         []string
+	registrationErrors map[string]error
+}
+
+// newGrafanaMetrics creates the update checker metrics, labelled with the
+// running version and edition so fleet-wide dashboards can slice by them. Both
+// are fixed for the lifetime of an instance, so they don't add cardinality.
+func newGrafanaMetrics(reg prometheus.Registerer, grafanaVersion, edition string) *grafanaMetrics {
+	constLabels := prometheus.Labels{"version": grafanaVersion, "edition": edition}
+	m := &grafanaMetrics{
+		constLabels:        constLabels,
+		registrationErrors: map[string]error{},
+	}
+
+	m.checks = registerCollector(m, reg, "checks_total", prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   metricsNamespace,
+		Subsystem:   metricsSubsystem,
+		Name:        "checks_total",
+		ConstLabels: constLabels,
+		Help:        "Number of Grafana update checks by result",
+	}, []string{"result"}))
+	m.checkDuration = registerCollector(m, reg, "check_duration_seconds", prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace:   metricsNamespace,
+		Subsystem:   metricsSubsystem,
+		Name:        "check_duration_seconds",
+		ConstLabels: constLabels,
+		Help:        "Duration of Grafana update checks",
+		Buckets:     prometheus.DefBuckets,
+	}))
+	m.updateAvailable = registerCollector(m, reg, "update_available", prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace:   metricsNamespace,
+		Subsystem:   metricsSubsystem,
+		Name:        "update_available",
+		ConstLabels: constLabels,
+		Help:        "1 if a newer Grafana version is available, 0 otherwise",
+	}))
+	m.lastSuccess = registerCollector(m, reg, "last_success_timestamp_seconds", prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace:   metricsNamespace,
+		Subsystem:   metricsSubsystem,
+		Name:        "last_success_timestamp_seconds",
+		ConstLabels: constLabels,
+		Help:        "Unix timestamp of the last successful Grafana update check",
+	}))
+	m.receivedBytes = registerCollector(m, reg, "received_bytes_total", prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   metricsNamespace,
+		Subsystem:   metricsSubsystem,
+		Name:        "received_bytes_total",
+		ConstLabels: constLabels,
+		Help:        "Bytes of update server responses received by Grafana update checks, on the wire and after decompression",
+	}, []string{"size"}))
+	m.enabled = registerCollector(m, reg, "enabled", prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace:   metricsNamespace,
+		Subsystem:   metricsSubsystem,
+		Name:        "enabled",
+		ConstLabels: constLabels,
+		Help:        "1 if Grafana update checks are enabled, 0 otherwise",
+	}))
+
+	return m
+}
+
+// registerConnectionMetrics creates the metrics reported by connection
+// tracing.
+func (m *grafanaMetrics) registerConnectionMetrics(reg prometheus.Registerer) {
+	m.connections = registerCollector(m, reg, "connections_total", prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   metricsNamespace,
+		Subsystem:   metricsSubsystem,
+		Name:        "connections_total",
+		ConstLabels: m.constLabels,
+		Help:        "Number of connections used by Grafana update check requests, by whether they were reused",
+	}, []string{"reused"}))
+	m.connectionPhases = registerCollector(m, reg, "connection_phase_duration_seconds", prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace:   metricsNamespace,
+		Subsystem:   metricsSubsystem,
+		Name:        "connection_phase_duration_seconds",
+		ConstLabels: m.constLabels,
+		Help:        "Duration of DNS resolution, connecting and the TLS handshake of Grafana update check requests",
+		Buckets:     prometheus.DefBuckets,
+	}, []string{"phase"}))
+}
+
+// registerCollector registers c without panicking. A collector that is
+// already registered is reused, any other failure is recorded so that it can
+// be reported instead of crashing the server.
+func registerCollector[T prometheus.Collector](m *grafanaMetrics, reg prometheus.Registerer, name string, c T) T {
+	name = prometheus.BuildFQName(metricsNamespace, metricsSubsystem, name)
+	if reg == nil {
+		return c
+	}
+
+	if err := reg.Register(c); err != nil {
+		var alreadyRegistered prometheus.AlreadyRegisteredError
+		if errors.As(err, &alreadyRegistered) {
+			if existing, ok := alreadyRegistered.ExistingCollector.(T); ok {
+				m.registered = append(m.registered, name)
+				return existing
+			}
+		}
+
+		m.registrationErrors[name] = err
+		return c
+	}
+
+	m.registered = append(m.registered, name)
+	return c
+}
+
+// RegisteredMetrics returns the names of the update checker metrics that were
+// successfully registered, to help tell missing metrics apart from metrics that
+// are registered but never updated.
+func (s *GrafanaService) RegisteredMetrics() []string {
+	registered := make([]string, len(s.metrics.registered))
+	copy(registered, s.metrics.registered)
+	return registered
+}
+
+// WriteTextfile renders the update checker metrics in the Prometheus text
+// format, for environments that can't scrape Grafana but can have a cron job
+// write them to the textfile directory of node_exporter.
+func (s *GrafanaService) WriteTextfile(w io.Writer) error {
+	reg := prometheus.NewRegistry()
+	for _, c := range s.metrics.collectors() {
+		if err := reg.Register(c); err != nil {
+			return err
+		}
+	}
+
+	families, err := reg.Gather()
+	if err != nil {
+		return err
+	}
+	for _, mf := range families {
+		if _, err := expfmt.MetricFamilyToText(w, mf); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// observeCheckDuration records the duration of a check, with the trace of the
+// check as an exemplar when it's sampled.
+func (s *GrafanaService) observeCheckDuration(ctx context.Context, d time.Duration) {
+	if traceID := tracing.TraceIDFromContext(ctx, true); traceID != "" {
+		// Need to type-convert the Histogram to an ExemplarObserver. This
+		// will always work for a Histogram.
+		s.metrics.checkDuration.(prometheus.ExemplarObserver).ObserveWithExemplar(
+			d.Seconds(), prometheus.Labels{"traceID": traceID},
+		)
+		return
+	}
+	s.metrics.checkDuration.Observe(d.Seconds())
+}
+
+func (m *grafanaMetrics) collectors() []prometheus.Collector {
+	collectors := []prometheus.Collector{m.checks, m.checkDuration, m.updateAvailable, m.lastSuccess, m.receivedBytes, m.enabled}
+	if m.connections != nil {
+		collectors = append(collectors, m.connections, m.connectionPhases)
+	}
+	return collectors
+}
+
+func grafanaEdition(isEnterprise bool) string {
+	if isEnterprise {
+		return "enterprise"
+	}
+	return "oss"
+}
+
+func boolToFloat64(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,303 @@
+package updatechecker
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestGrafanaUpdateChecker_RegisteredMetrics(t *testing.T) {
+	t.Run("all collectors are registered", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{})
+		svc.metrics = newGrafanaMetrics(prometheus.NewRegistry(), "9.3.0", "oss")
+
+		require.Equal(t, []string{
+			"grafana_update_checker_checks_total",
+			"grafana_update_checker_check_duration_seconds",
+			"grafana_update_checker_update_available",
+			"grafana_update_checker_last_success_timestamp_seconds",
+			"grafana_update_checker_received_bytes_total",
+			"grafana_update_checker_enabled",
+		}, svc.RegisteredMetrics())
+		require.Empty(t, svc.metrics.registrationErrors)
+	})
+
+	t.Run("registering twice reuses the existing collectors", func(t *testing.T) {
+		reg := prometheus.NewRegistry()
+		first := newGrafanaMetrics(reg, "9.3.0", "oss")
+		second := newGrafanaMetrics(reg, "9.3.0", "oss")
+
+		require.Len(t, second.registered, 6)
+		require.Empty(t, second.registrationErrors)
+		require.Same(t, first.updateAvailable, second.updateAvailable)
+	})
+
+	t.Run("conflicting collector is reported instead of panicking", func(t *testing.T) {
+		reg := prometheus.NewRegistry()
+		reg.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "grafana_update_checker_update_available",
+			Help: "something else entirely",
+		}))
+
+		m := newGrafanaMetrics(reg, "9.3.0", "oss")
+		require.NotContains(t, m.registered, "grafana_update_checker_update_available")
+		require.Contains(t, m.registrationErrors, "grafana_update_checker_update_available")
+		require.Len(t, m.registered, 5)
+	})
+}
+
+func TestGrafanaUpdateChecker_instrumentedCheckForUpdates(t *testing.T) {
+	client := &fakeHTTPClient{fakeResp: `{"stable": "9.3.1"}`}
+	svc := newTestGrafanaService("9.3.0", client)
+
+	require.NoError(t, svc.instrumentedCheckForUpdates(context.Background()))
+	require.Equal(t, 1.0, testutil.ToFloat64(svc.metrics.checks.WithLabelValues("success_changed")))
+	require.Equal(t, 1.0, testutil.ToFloat64(svc.metrics.updateAvailable))
+	require.Equal(t, float64(svc.clock.Now().Unix()), testutil.ToFloat64(svc.metrics.lastSuccess))
+
+	client.fakeResp = `not json`
+	require.Error(t, svc.instrumentedCheckForUpdates(context.Background()))
+	require.Equal(t, 1.0, testutil.ToFloat64(svc.metrics.checks.WithLabelValues("failure")))
+	require.Equal(t, 1.0, testutil.ToFloat64(svc.metrics.updateAvailable))
+}
+
+func TestGrafanaUpdateChecker_checkDurationExemplar(t *testing.T) {
+	exemplars := func(svc *GrafanaService) []*dto.Exemplar {
+		var m dto.Metric
+		require.NoError(t, svc.metrics.checkDuration.Write(&m))
+		var exemplars []*dto.Exemplar
+		for _, b := range m.GetHistogram().GetBucket() {
+			if b.GetExemplar() != nil {
+				exemplars = append(exemplars, b.GetExemplar())
+			}
+		}
+		return exemplars
+	}
+
+	t.Run("links to the trace of the check", func(t *testing.T) {
+		traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+		require.NoError(t, err)
+		spanID, err := trace.SpanIDFromHex("00f067aa0ba902b7")
+		require.NoError(t, err)
+		ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    traceID,
+			SpanID:     spanID,
+			TraceFlags: trace.FlagsSampled,
+		}))
+
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"stable": "9.3.1"}`})
+		require.NoError(t, svc.instrumentedCheckForUpdates(ctx))
+
+		observed := exemplars(svc)
+		require.Len(t, observed, 1)
+		labels := observed[0].GetLabel()
+		require.Len(t, labels, 1)
+		require.Equal(t, "traceID", labels[0].GetName())
+		require.Equal(t, traceID.String(), labels[0].GetValue())
+	})
+
+	t.Run("none without a sampled trace", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"stable": "9.3.1"}`})
+		require.NoError(t, svc.instrumentedCheckForUpdates(context.Background()))
+		require.Empty(t, exemplars(svc))
+	})
+}
+
+func TestGrafanaUpdateChecker_metricLabels(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"stable": "9.3.1"}`})
+	svc.metrics = newGrafanaMetrics(reg, "9.3.0", "enterprise")
+	require.NoError(t, svc.instrumentedCheckForUpdates(context.Background()))
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	require.Len(t, families, 6)
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			require.Equal(t, "9.3.0", labels["version"], family.GetName())
+			require.Equal(t, "enterprise", labels["edition"], family.GetName())
+		}
+	}
+
+	expected := `
+# HELP grafana_update_checker_update_available 1 if a newer Grafana version is available, 0 otherwise
+# TYPE grafana_update_checker_update_available gauge
+grafana_update_checker_update_available{edition="enterprise",version="9.3.0"} 1
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "grafana_update_checker_update_available"))
+}
+
+func TestGrafanaUpdateChecker_receivedBytes(t *testing.T) {
+	t.Run("uncompressed response", func(t *testing.T) {
+		const payload = `{"stable": "9.3.1", "testing": "9.4.0-beta1"}`
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: payload})
+
+		_, err := svc.checkForUpdates(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, float64(len(payload)), testutil.ToFloat64(svc.metrics.receivedBytes.WithLabelValues("wire")))
+		require.Equal(t, float64(len(payload)), testutil.ToFloat64(svc.metrics.receivedBytes.WithLabelValues("decompressed")))
+
+		_, err = svc.checkForUpdates(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, float64(2*len(payload)), testutil.ToFloat64(svc.metrics.receivedBytes.WithLabelValues("decompressed")))
+	})
+
+	t.Run("gzip encoded response", func(t *testing.T) {
+		payload := `{"stable": "9.3.1", "releases": ["` + strings.Repeat(`9.3.1", "`, 100) + `9.3.1"]}`
+		var compressed bytes.Buffer
+		gz := gzip.NewWriter(&compressed)
+		_, err := gz.Write([]byte(payload))
+		require.NoError(t, err)
+		require.NoError(t, gz.Close())
+
+		client := &routingHTTPClient{routes: map[string]routedResponse{
+			defaultLatestJSONURL: {
+				statusCode: http.StatusOK,
+				header:     http.Header{"Content-Encoding": []string{"gzip"}},
+				body:       compressed.String(),
+			},
+		}}
+		svc := newTestGrafanaService("9.3.0", client)
+
+		_, err = svc.checkForUpdates(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "9.3.1", svc.LatestVersion())
+		require.Equal(t, float64(compressed.Len()), testutil.ToFloat64(svc.metrics.receivedBytes.WithLabelValues("wire")))
+		require.Equal(t, float64(len(payload)), testutil.ToFloat64(svc.metrics.receivedBytes.WithLabelValues("decompressed")))
+	})
+}
+
+func TestGrafanaUpdateChecker_checkResults(t *testing.T) {
+	client := &etagHTTPClient{etag: `"v1"`, body: `{"stable": "9.3.1"}`}
+	svc := newTestGrafanaService("9.3.0", client)
+	checks := func(result string) float64 {
+		return testutil.ToFloat64(svc.metrics.checks.WithLabelValues(result))
+	}
+
+	require.NoError(t, svc.instrumentedCheckForUpdates(context.Background()))
+	require.Equal(t, 1.0, checks("success_changed"))
+	require.Empty(t, client.ifNoneMatch)
+
+	require.NoError(t, svc.instrumentedCheckForUpdates(context.Background()))
+	require.Equal(t, 1.0, checks("not_modified"))
+	require.Equal(t, `"v1"`, client.ifNoneMatch)
+	require.Equal(t, "9.3.1", svc.LatestVersion())
+
+	// served again without an ETag match, but nothing changed
+	client.etag, client.body = `"v2"`, `{"stable": "9.3.1"}`
+	require.NoError(t, svc.instrumentedCheckForUpdates(context.Background()))
+	require.Equal(t, 1.0, checks("success_unchanged"))
+
+	client.etag, client.body = `"v3"`, `{"stable": "9.3.2"}`
+	require.NoError(t, svc.instrumentedCheckForUpdates(context.Background()))
+	require.Equal(t, 2.0, checks("success_changed"))
+	require.Equal(t, "9.3.2", svc.LatestVersion())
+
+	require.Equal(t, 1.0, checks("not_modified"))
+	require.Equal(t, 1.0, checks("success_unchanged"))
+	require.Zero(t, checks("failure"))
+}
+
+// etagHTTPClient serves body with an ETag, and replies 304 Not Modified to
+// requests whose If-None-Match matches it.
+type etagHTTPClient struct {
+	etag string
+	body string
+
+	ifNoneMatch string
+}
+
+func (c *etagHTTPClient) Get(url string) (*http.Response, error) {
+	return nil, errors.New("conditional requests need Do")
+}
+
+func (c *etagHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	c.ifNoneMatch = req.Header.Get("If-None-Match")
+	if c.ifNoneMatch == c.etag {
+		return &http.Response{
+			StatusCode: http.StatusNotModified,
+			Header:     http.Header{"Etag": []string{c.etag}},
+			Body:       io.NopCloser(strings.NewReader("")),
+		}, nil
+	}
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Etag": []string{c.etag}},
+		Body:       io.NopCloser(strings.NewReader(c.body)),
+	}, nil
+}
+
+func TestGrafanaUpdateChecker_WriteTextfile(t *testing.T) {
+	svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"stable": "9.3.1"}`})
+	svc.clock.(*clock.Mock).Add(time.Hour)
+	require.NoError(t, svc.instrumentedCheckForUpdates(context.Background()))
+
+	var buf bytes.Buffer
+	require.NoError(t, svc.WriteTextfile(&buf))
+	text := buf.String()
+
+	require.Contains(t, text, `# HELP grafana_update_checker_update_available 1 if a newer Grafana version is available, 0 otherwise
+# TYPE grafana_update_checker_update_available gauge
+grafana_update_checker_update_available{edition="oss",version="9.3.0"} 1
+`)
+	require.Contains(t, text, `# TYPE grafana_update_checker_last_success_timestamp_seconds gauge
+grafana_update_checker_last_success_timestamp_seconds{edition="oss",version="9.3.0"} 3600
+`)
+
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(strings.NewReader(text))
+	require.NoError(t, err)
+	require.Contains(t, families, "grafana_update_checker_update_available")
+	require.Contains(t, families, "grafana_update_checker_last_success_timestamp_seconds")
+}
+
+func TestGrafanaUpdateChecker_enabledMetric(t *testing.T) {
+	t.Run("reflects the configured state", func(t *testing.T) {
+		for _, enabled := range []bool{true, false} {
+			cfg := setting.NewCfg()
+			cfg.BuildVersion = "9.3.0"
+			cfg.CheckForGrafanaUpdates = enabled
+			svc := provideTestGrafanaService(cfg)
+
+			require.Equal(t, !enabled, svc.IsDisabled())
+			require.Equal(t, boolToFloat64(enabled), testutil.ToFloat64(svc.metrics.enabled))
+		}
+	})
+
+	t.Run("flips when toggled at runtime", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.BuildVersion = "9.3.0"
+		cfg.CheckForGrafanaUpdates = true
+		svc := provideTestGrafanaService(cfg)
+
+		svc.SetEnabled(false)
+		require.True(t, svc.IsDisabled())
+		require.Equal(t, 0.0, testutil.ToFloat64(svc.metrics.enabled))
+		require.ErrorIs(t, svc.CheckNow(context.Background()), ErrChecksDisabled)
+
+		svc.SetEnabled(true)
+		require.False(t, svc.IsDisabled())
+		require.Equal(t, 1.0, testutil.ToFloat64(svc.metrics.enabled))
+	})
+}
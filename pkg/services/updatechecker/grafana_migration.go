@@ -0,0 +1,12 @@
+package updatechecker
+
+// LatestRequiresMigration reports whether upgrading to the latest version runs
+// database migrations, because the update server flags it or a version in
+// between, so that operators can plan the downtime. It's false without an
+// update or when the update server doesn't flag migrations.
+func (s *GrafanaService) LatestRequiresMigration() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.requiresMigration
+}
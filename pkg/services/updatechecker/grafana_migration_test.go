@@ -0,0 +1,51 @@
+package updatechecker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrafanaUpdateChecker_LatestRequiresMigration(t *testing.T) {
+	tests := []struct {
+		name     string
+		resp     string
+		expected bool
+	}{
+		{
+			name:     "latest requires a migration",
+			resp:     `{"stable": "9.4.0", "requiresMigration": {"9.4.0": true}}`,
+			expected: true,
+		},
+		{
+			name:     "a version in between requires a migration",
+			resp:     `{"stable": "9.4.1", "requiresMigration": {"9.4.0": true, "9.4.1": false}}`,
+			expected: true,
+		},
+		{
+			name: "latest doesn't require a migration",
+			resp: `{"stable": "9.3.1", "requiresMigration": {"9.3.1": false}}`,
+		},
+		{
+			name: "only older and newer versions require migrations",
+			resp: `{"stable": "9.3.1", "requiresMigration": {"9.3.0": true, "9.4.0": true}}`,
+		},
+		{
+			name: "no migration data",
+			resp: `{"stable": "9.4.0"}`,
+		},
+		{
+			name: "no update",
+			resp: `{"stable": "9.3.0", "requiresMigration": {"9.3.0": true}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: tt.resp})
+			require.NoError(t, svc.CheckNow(context.Background()))
+			require.Equal(t, tt.expected, svc.LatestRequiresMigration())
+		})
+	}
+}
@@ -0,0 +1,331 @@
+package updatechecker
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/grafana/grafana/pkg/infra/tracing"
+)
+
+const defaultLatestJSONURL = "https://raw.githubusercontent.com/grafana/grafana/main/latest.json"
+
+// MirrorStatus is the health of an update server as seen by the most recent
+// check that contacted it.
+type MirrorStatus struct {
+	URL            string    `json:"url"`
+	LastSuccess    time.Time `json:"lastSuccess"`
+	LastError      string    `json:"lastError"`
+	LastStatusCode int       `json:"lastStatusCode"`
+}
+
+func newMirrorStatuses(urls []string) []MirrorStatus {
+	if len(urls) == 0 {
+		urls = []string{defaultLatestJSONURL}
+	}
+
+	mirrors := make([]MirrorStatus, 0, len(urls))
+	for _, url := range urls {
+		mirrors = append(mirrors, MirrorStatus{URL: url})
+	}
+
+	return mirrors
+}
+
+// Mirrors returns the configured update servers, in the order they are tried,
+// together with their last seen health.
+func (s *GrafanaService) Mirrors() []MirrorStatus {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	mirrors := make([]MirrorStatus, len(s.mirrors))
+	copy(mirrors, s.mirrors)
+	return mirrors
+}
+
+// fetchLatest tries the configured mirrors in order and returns the body, URL
+// and status code of the first successful response, falling back to the next
+// mirror on failure.
+func (s *GrafanaService) fetchLatest(ctx context.Context, span tracing.Span) (fetchedPayload, string, int, error) {
+	s.mutex.RLock()
+	urls := make([]string, 0, len(s.mirrors))
+	for _, m := range s.mirrors {
+		urls = append(urls, m.URL)
+	}
+	s.mutex.RUnlock()
+
+	var errs error
+	for i, url := range urls {
+		payload, statusCode, err := s.fetchFrom(ctx, span, url)
+
+		s.mutex.Lock()
+		// The mirrors may have been replaced while fetching.
+		if i < len(s.mirrors) && s.mirrors[i].URL == url {
+			s.mirrors[i].LastStatusCode = statusCode
+			if err != nil {
+				s.mirrors[i].LastError = err.Error()
+			} else {
+				s.mirrors[i].LastSuccess = s.clock.Now()
+				s.mirrors[i].LastError = ""
+			}
+		}
+		s.mutex.Unlock()
+
+		if err == nil {
+			return payload, url, statusCode, nil
+		}
+		s.log.Debug("Failed to get latest.json from mirror", "url", url, "error", err)
+		errs = multierror.Append(errs, fmt.Errorf("%s: %w", url, err))
+	}
+
+	return fetchedPayload{}, "", 0, errs
+}
+
+// regionQueryParam tags requests for latest.json with the configured region
+// of the instance, for geo analytics or routing on the update server.
+const regionQueryParam = "region"
+
+func (s *GrafanaService) fetchFrom(ctx context.Context, span tracing.Span, url string) (fetchedPayload, int, error) {
+	digest := s.latestDigest(ctx, url)
+	if payload, ok := s.cachedByDigest(url, digest); ok {
+		s.log.Debug("latest.json digest is unchanged, skipping the download", "url", url, "digest", digest)
+		return payload, http.StatusNotModified, nil
+	}
+
+	req, err := s.newLatestRequest(ctx, url)
+	if err != nil {
+		return fetchedPayload{}, 0, err
+	}
+	s.tracer.Inject(ctx, req.Header, span)
+	if id := s.instanceID(ctx); id != "" {
+		req.Header.Set(instanceIDHeader, id)
+	}
+	cached, hasCached := s.cachedResponse(url)
+	if hasCached {
+		req.Header.Set("If-None-Match", cached.etag)
+	}
+
+	req, traced := s.traceConnection(req)
+	resp, err := s.httpClient.Do(req)
+	traced()
+	if err != nil {
+		return fetchedPayload{}, 0, err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			s.log.Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	s.detectClockSkew(url, resp.Header)
+
+	if resp.StatusCode == http.StatusNotModified && hasCached {
+		return cached.payload, resp.StatusCode, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fetchedPayload{}, resp.StatusCode, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	payload, err := s.decodeResponse(resp, s.payloadKey)
+	if err != nil {
+		return fetchedPayload{}, resp.StatusCode, err
+	}
+	s.cacheResponse(url, resp.Header.Get("ETag"), payload)
+	if digest != "" {
+		s.cacheDigest(url, digest, payload)
+	}
+
+	return payload, resp.StatusCode, nil
+}
+
+// latestRequestBody is sent to update servers configured for POST requests,
+// so that they can tailor the response to the instance.
+type latestRequestBody struct {
+	Version string `json:"version"`
+	Edition string `json:"edition"`
+}
+
+// newLatestRequest builds a request for url using the configured method,
+// tagged with the region of the instance and accepting the supported encodings.
+func (s *GrafanaService) newLatestRequest(ctx context.Context, url string) (*http.Request, error) {
+	req, err := s.newMethodRequest(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	if s.region != "" {
+		query := req.URL.Query()
+		query.Set(regionQueryParam, s.region)
+		req.URL.RawQuery = query.Encode()
+	}
+	// Asking for gzip explicitly turns off the transparent decompression of
+	// the transport, so that the on-wire size can be accounted for.
+	req.Header.Set("Accept-Encoding", "gzip")
+	req.Header.Set("Accept", latestAccept)
+	return req, nil
+}
+
+// newMethodRequest builds a request for url using the configured method. POST
+// requests carry the version and edition of the instance as a JSON body.
+func (s *GrafanaService) newMethodRequest(ctx context.Context, url string) (*http.Request, error) {
+	if s.method != http.MethodPost {
+		return http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	}
+
+	grafanaVersion, _ := s.runningVersion()
+	body, err := json.Marshal(latestRequestBody{Version: grafanaVersion, Edition: s.edition})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+// maxLatestJSONSize bounds the decompressed size of latest.json.
+const maxLatestJSONSize = 16 << 20
+
+// fetchedPayload is a latest.json as decoded from an update server response.
+type fetchedPayload struct {
+	latest latestJSON
+	// err is why the payload couldn't be decoded. Unlike a failed request it
+	// fails the check rather than moving on to the next mirror.
+	err error
+	// head is the start of the payload, kept for support bundles, and
+	// truncated is set when the payload is longer than that.
+	head      []byte
+	truncated bool
+}
+
+// decodeResponse decodes the latest.json in resp, decompressing it if it's
+// gzip encoded, and records both the on-wire and the decompressed size. JSON
+// is decoded as it's read, within the size limit, instead of being read into
+// memory first. Only MessagePack, which is converted to JSON, is buffered.
+// The returned error is set when the response can't be read, the error of
+// the payload when it can't be decoded.
+func (s *GrafanaService) decodeResponse(resp *http.Response, keyPath string) (fetchedPayload, error) {
+	if isMessagePack(resp.Header.Get("Content-Type")) {
+		body, err := s.readBody(resp)
+		if err != nil {
+			return fetchedPayload{}, fmt.Errorf("failed to read response: %w", err)
+		}
+		body, err = toLatestJSON(resp.Header.Get("Content-Type"), body)
+		if err != nil {
+			return fetchedPayload{}, fmt.Errorf("failed to decode MessagePack response: %w", err)
+		}
+		return decodePayload(bytes.NewReader(body), keyPath), nil
+	}
+
+	wire := &countingReader{r: resp.Body}
+	var r io.Reader = wire
+	if strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		gz, err := gzip.NewReader(wire)
+		if err != nil {
+			s.metrics.receivedBytes.WithLabelValues("wire").Add(float64(wire.n))
+			return fetchedPayload{}, fmt.Errorf("failed to read response: %w", err)
+		}
+		r = gz
+	}
+
+	body := &limitedBodyReader{r: r, max: maxLatestJSONSize}
+	payload := decodePayload(body, keyPath)
+	s.metrics.receivedBytes.WithLabelValues("wire").Add(float64(wire.n))
+	s.metrics.receivedBytes.WithLabelValues("decompressed").Add(float64(body.n))
+	if body.err != nil {
+		return fetchedPayload{}, fmt.Errorf("failed to read response: %w", body.err)
+	}
+	return payload, nil
+}
+
+// decodePayload decodes the latest.json read from r, keeping its start.
+func decodePayload(r io.Reader, keyPath string) fetchedPayload {
+	head := &payloadHead{max: maxSupportBundlePayloadSize}
+	latest, err := decodeLatestJSONFrom(io.TeeReader(r, head), keyPath)
+	// Payloads are cached and reused across checks, appending pages to one
+	// must not write into the releases of the cached one.
+	latest.Releases = latest.Releases[:len(latest.Releases):len(latest.Releases)]
+	return fetchedPayload{latest: latest, err: err, head: head.buf, truncated: head.truncated}
+}
+
+// limitedBodyReader reads at most max bytes of a response body, keeping the
+// first error other than io.EOF so that failures to read the body can be told
+// apart from malformed payloads.
+type limitedBodyReader struct {
+	r   io.Reader
+	max int64
+	n   int64
+	err error
+}
+
+func (l *limitedBodyReader) Read(p []byte) (int, error) {
+	if l.err != nil {
+		return 0, l.err
+	}
+
+	n, err := l.r.Read(p)
+	l.n += int64(n)
+	switch {
+	case l.n > l.max:
+		l.err = fmt.Errorf("response exceeds %d bytes", l.max)
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		l.err = fmt.Errorf("%w after %d bytes: %v", errTruncatedPayload, l.n, err)
+	case err != nil && err != io.EOF:
+		l.err = err
+	default:
+		return n, err
+	}
+	return n, l.err
+}
+
+// readBody reads a response body, decompressing it if it's gzip encoded, and
+// records both the on-wire and the decompressed size. It's only used for
+// MessagePack payloads, which have to be held in memory, see decodeResponse.
+func (s *GrafanaService) readBody(resp *http.Response) ([]byte, error) {
+	wire := &countingReader{r: resp.Body}
+	var r io.Reader = wire
+	if strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		gz, err := gzip.NewReader(wire)
+		if err != nil {
+			s.metrics.receivedBytes.WithLabelValues("wire").Add(float64(wire.n))
+			return nil, err
+		}
+		r = gz
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r, maxLatestJSONSize+1))
+	s.metrics.receivedBytes.WithLabelValues("wire").Add(float64(wire.n))
+	s.metrics.receivedBytes.WithLabelValues("decompressed").Add(float64(len(body)))
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("%w after %d bytes: %v", errTruncatedPayload, len(body), err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxLatestJSONSize {
+		return nil, fmt.Errorf("response exceeds %d bytes", maxLatestJSONSize)
+	}
+
+	return body, nil
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,41 @@
+package updatechecker
+
+import (
+	"encoding/json"
+	"mime"
+	"reflect"
+
+	"github.com/hashicorp/go-msgpack/codec"
+)
+
+// latestAccept prefers MessagePack, a compact binary encoding of latest.json
+// that mirrors serving large release indices may offer, over JSON.
+const latestAccept = "application/msgpack, application/x-msgpack;q=0.9, application/json;q=0.8"
+
+var msgpackContentTypes = map[string]bool{
+	"application/msgpack":     true,
+	"application/x-msgpack":   true,
+	"application/vnd.msgpack": true,
+}
+
+// toLatestJSON returns body as JSON, converting it if the content type says
+// it's MessagePack, so that the rest of the check only has to deal with JSON.
+func toLatestJSON(contentType string, body []byte) ([]byte, error) {
+	if !isMessagePack(contentType) {
+		return body, nil
+	}
+
+	h := &codec.MsgpackHandle{RawToString: true}
+	h.MapType = reflect.TypeOf(map[string]interface{}(nil))
+
+	var v interface{}
+	if err := codec.NewDecoderBytes(body, h).Decode(&v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+func isMessagePack(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && msgpackContentTypes[mediaType]
+}
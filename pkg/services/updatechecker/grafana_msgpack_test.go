@@ -0,0 +1,73 @@
+package updatechecker
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/go-msgpack/codec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrafanaUpdateChecker_msgpack(t *testing.T) {
+	const jsonPayload = `{
+		"stable": "9.4.0",
+		"testing": "9.5.0-beta1",
+		"security": true,
+		"releaseDates": {"9.4.0": "2023-02-28"},
+		"builds": {"9.4.0": {"commit": "abc123", "timestamp": "2023-02-28T10:00:00Z"}},
+		"supported": ["9.x", "8.x"]
+	}`
+	var msgpackPayload []byte
+	require.NoError(t, codec.NewEncoderBytes(&msgpackPayload, &codec.MsgpackHandle{}).Encode(map[string]interface{}{
+		"stable":       "9.4.0",
+		"testing":      "9.5.0-beta1",
+		"security":     true,
+		"releaseDates": map[string]interface{}{"9.4.0": "2023-02-28"},
+		"builds":       map[string]interface{}{"9.4.0": map[string]interface{}{"commit": "abc123", "timestamp": "2023-02-28T10:00:00Z"}},
+		"supported":    []string{"9.x", "8.x"},
+	}))
+
+	read := func(t *testing.T, contentType string, body []byte) latestJSON {
+		t.Helper()
+
+		client := &fakeHTTPClient{fakeResp: string(body)}
+		svc := newTestGrafanaService("9.3.0", &contentTypeHTTPClient{fakeHTTPClient: client, contentType: contentType})
+		ctx, span := svc.tracer.Start(context.Background(), "test")
+		defer span.End()
+
+		fetched, _, err := svc.fetchFrom(ctx, span, defaultLatestJSONURL)
+		require.NoError(t, err)
+		latest, err := svc.readLatestJSON(ctx, span, defaultLatestJSONURL, fetched)
+		require.NoError(t, err)
+
+		require.Equal(t, latestAccept, client.requestHeader.Get("Accept"))
+		return latest
+	}
+
+	fromJSON := read(t, "application/json; charset=utf-8", []byte(jsonPayload))
+	require.Equal(t, "9.4.0", fromJSON.Stable)
+	require.Equal(t, fromJSON, read(t, "application/msgpack", msgpackPayload))
+	require.Equal(t, fromJSON, read(t, "application/x-msgpack", msgpackPayload))
+
+	t.Run("invalid MessagePack fails the check", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &contentTypeHTTPClient{fakeHTTPClient: &fakeHTTPClient{fakeResp: "\xc1"}, contentType: "application/msgpack"})
+		require.ErrorContains(t, svc.CheckNow(context.Background()), "failed to decode MessagePack response")
+	})
+}
+
+// contentTypeHTTPClient serves the response of a fakeHTTPClient with the
+// given content type.
+type contentTypeHTTPClient struct {
+	*fakeHTTPClient
+	contentType string
+}
+
+func (c *contentTypeHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	resp, err := c.fakeHTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Header = http.Header{"Content-Type": []string{c.contentType}}
+	return resp, nil
+}
@@ -0,0 +1,33 @@
+package updatechecker
+
+// AcknowledgeBanner records that the update banner has been shown, e.g. by
+// the frontend, so that ShouldNag holds it back for the nag interval.
+func (s *GrafanaService) AcknowledgeBanner() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.bannerAckedAt = s.clock.Now()
+}
+
+// ShouldNag reports whether the update banner should be shown now: there's a
+// banner message, and it hasn't been acknowledged within the nag interval.
+// Without an interval it's shown for as long as there's a message.
+func (s *GrafanaService) ShouldNag() bool {
+	if s.BannerMessage() == "" {
+		return false
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return !s.nagThrottled()
+}
+
+// nagThrottled reports whether the banner was acknowledged within the nag
+// interval. The caller must hold the mutex.
+func (s *GrafanaService) nagThrottled() bool {
+	if s.nagInterval <= 0 || s.bannerAckedAt.IsZero() {
+		return false
+	}
+	return s.clock.Since(s.bannerAckedAt) < s.nagInterval
+}
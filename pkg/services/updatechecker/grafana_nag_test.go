@@ -0,0 +1,56 @@
+package updatechecker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrafanaUpdateChecker_ShouldNag(t *testing.T) {
+	t.Run("re-surfaces once per interval after an acknowledgment", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"stable": "9.3.1"}`})
+		svc.nagInterval = 24 * time.Hour
+		mock := svc.clock.(*clock.Mock)
+		mock.Set(time.Date(2023, time.March, 1, 9, 0, 0, 0, time.UTC))
+		require.NoError(t, svc.CheckNow(context.Background()))
+
+		require.True(t, svc.ShouldNag())
+		svc.AcknowledgeBanner()
+		require.False(t, svc.ShouldNag())
+
+		mock.Add(23 * time.Hour)
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.False(t, svc.ShouldNag())
+		require.True(t, svc.UpdateAvailable())
+
+		mock.Add(time.Hour)
+		require.True(t, svc.ShouldNag())
+		svc.AcknowledgeBanner()
+		require.False(t, svc.ShouldNag())
+	})
+
+	t.Run("without an interval nags while an update is available", func(t *testing.T) {
+		client := &fakeHTTPClient{fakeResp: `{"stable": "9.3.1"}`}
+		svc := newTestGrafanaService("9.3.0", client)
+		require.NoError(t, svc.CheckNow(context.Background()))
+
+		svc.AcknowledgeBanner()
+		require.True(t, svc.ShouldNag())
+
+		client.fakeResp = `{"stable": "9.3.0"}`
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.False(t, svc.ShouldNag())
+	})
+
+	t.Run("doesn't nag about dismissed versions", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"stable": "9.3.1"}`})
+		svc.nagInterval = time.Hour
+		require.NoError(t, svc.CheckNow(context.Background()))
+
+		svc.Dismiss("9.3.1")
+		require.False(t, svc.ShouldNag())
+	})
+}
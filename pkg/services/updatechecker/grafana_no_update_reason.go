@@ -0,0 +1,51 @@
+package updatechecker
+
+// Reasons returned by NoUpdateReason.
+const (
+	NoUpdateReasonUpToDate      = "up_to_date"
+	NoUpdateReasonAheadOfLatest = "ahead_of_latest"
+	NoUpdateReasonNeverChecked  = "never_checked"
+	NoUpdateReasonCheckFailing  = "check_failing"
+	NoUpdateReasonDisabled      = "disabled"
+	NoUpdateReasonSnoozed       = "snoozed"
+	NoUpdateReasonDismissed     = "dismissed"
+	NoUpdateReasonManaged       = "managed"
+	NoUpdateReasonNagThrottled  = "nag_throttled"
+)
+
+// NoUpdateReason explains why no update banner is shown, e.g. for support
+// engineers troubleshooting an instance. It's empty when the banner is shown,
+// and explains hidden banners of available updates too, e.g. in managed mode
+// or while the nag interval holds an acknowledged banner back.
+func (s *GrafanaService) NoUpdateReason() string {
+	if s.IsDisabled() {
+		return NoUpdateReasonDisabled
+	}
+	if s.isDismissed() {
+		return NoUpdateReasonDismissed
+	}
+	// Snoozing doesn't hide an update found before the snooze.
+	snoozed := s.isSnoozed()
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	switch {
+	case s.hasUpdate && s.bannerSuppressed:
+		return NoUpdateReasonManaged
+	case s.hasUpdate && s.nagThrottled():
+		return NoUpdateReasonNagThrottled
+	case s.hasUpdate:
+		return ""
+	case snoozed:
+		return NoUpdateReasonSnoozed
+	case s.consecutiveFailures > 0:
+		return NoUpdateReasonCheckFailing
+	case !s.checkedSuccessfully:
+		return NoUpdateReasonNeverChecked
+	case s.parsedGrafanaVersion != nil && s.parsedLatestVersion != nil && s.parsedGrafanaVersion.GreaterThan(s.parsedLatestVersion):
+		return NoUpdateReasonAheadOfLatest
+	default:
+		return NoUpdateReasonUpToDate
+	}
+}
@@ -0,0 +1,101 @@
+package updatechecker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrafanaUpdateChecker_NoUpdateReason(t *testing.T) {
+	t.Run("never checked", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"stable": "9.3.1"}`})
+		require.Equal(t, NoUpdateReasonNeverChecked, svc.NoUpdateReason())
+	})
+
+	t.Run("up to date", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"stable": "9.3.0"}`})
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.Equal(t, NoUpdateReasonUpToDate, svc.NoUpdateReason())
+	})
+
+	t.Run("ahead of latest", func(t *testing.T) {
+		svc := newTestGrafanaService("9.4.0", &fakeHTTPClient{fakeResp: `{"stable": "9.3.1"}`})
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.Equal(t, NoUpdateReasonAheadOfLatest, svc.NoUpdateReason())
+	})
+
+	t.Run("check failing", func(t *testing.T) {
+		client := &scriptedHTTPClient{responses: []scriptedResponse{
+			{body: `{"stable": "9.3.0"}`},
+			{err: errors.New("connection refused")},
+		}}
+		svc := newTestGrafanaService("9.3.0", client)
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.Error(t, svc.CheckNow(context.Background()))
+		require.Equal(t, NoUpdateReasonCheckFailing, svc.NoUpdateReason())
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"stable": "9.3.1"}`})
+		require.NoError(t, svc.CheckNow(context.Background()))
+		svc.SetEnabled(false)
+		require.Equal(t, NoUpdateReasonDisabled, svc.NoUpdateReason())
+	})
+
+	t.Run("snoozed", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"stable": "9.3.1"}`})
+		svc.Snooze(svc.clock.Now().Add(time.Hour))
+		require.Equal(t, NoUpdateReasonSnoozed, svc.NoUpdateReason())
+	})
+
+	t.Run("dismissed", func(t *testing.T) {
+		client := &fakeHTTPClient{fakeResp: `{"stable": "9.3.1"}`}
+		svc := newTestGrafanaService("9.3.0", client)
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.Empty(t, svc.NoUpdateReason())
+
+		svc.Dismiss("9.3.1")
+		require.Equal(t, NoUpdateReasonDismissed, svc.NoUpdateReason())
+		require.Empty(t, svc.BannerMessage())
+
+		client.fakeResp = `{"stable": "9.3.2"}`
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.Empty(t, svc.NoUpdateReason())
+		require.Equal(t, "Grafana 9.3.2 is available. You are running 9.3.0.", svc.BannerMessage())
+	})
+
+	t.Run("managed", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"stable": "9.3.1"}`})
+		svc.bannerSuppressed = true
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.True(t, svc.UpdateAvailable())
+		require.Empty(t, svc.BannerMessage())
+		require.Equal(t, NoUpdateReasonManaged, svc.NoUpdateReason())
+	})
+
+	t.Run("managed and up to date", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"stable": "9.3.0"}`})
+		svc.bannerSuppressed = true
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.Equal(t, NoUpdateReasonUpToDate, svc.NoUpdateReason())
+	})
+	t.Run("nag throttled", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"stable": "9.3.1"}`})
+		svc.nagInterval = 24 * time.Hour
+		mock := svc.clock.(*clock.Mock)
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.Empty(t, svc.NoUpdateReason())
+
+		svc.AcknowledgeBanner()
+		require.False(t, svc.ShouldNag())
+		require.Equal(t, NoUpdateReasonNagThrottled, svc.NoUpdateReason())
+
+		mock.Add(24 * time.Hour)
+		require.True(t, svc.ShouldNag())
+		require.Empty(t, svc.NoUpdateReason())
+	})
+}
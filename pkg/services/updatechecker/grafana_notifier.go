@@ -0,0 +1,79 @@
+package updatechecker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/services/ngalert"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+)
+
+// newVersionNotifier is told about every newly detected Grafana version.
+type newVersionNotifier interface {
+	NotifyNewVersion(ctx context.Context, currentVersion, latestVersion string) error
+}
+
+// contactPointNotifier sends new-version notifications through a contact point
+// of an organization's Alertmanager, using the same path as the "test contact
+// point" action.
+type contactPointNotifier struct {
+	alertNG      *ngalert.AlertNG
+	orgID        int64
+	contactPoint string
+}
+
+func (n *contactPointNotifier) NotifyNewVersion(ctx context.Context, currentVersion, latestVersion string) error {
+	if n.alertNG == nil || n.alertNG.MultiOrgAlertmanager == nil {
+		return errors.New("unified alerting is not available")
+	}
+
+	am, err := n.alertNG.MultiOrgAlertmanager.AlertmanagerFor(n.orgID)
+	if err != nil {
+		return err
+	}
+
+	status := am.GetStatus()
+	if status.Config == nil {
+		return errors.New("alertmanager has no configuration")
+	}
+
+	var receiver *apimodels.PostableApiReceiver
+	for _, r := range status.Config.Receivers {
+		if r.Name == n.contactPoint {
+			receiver = r
+			break
+		}
+	}
+	if receiver == nil {
+		return fmt.Errorf("contact point %q not found", n.contactPoint)
+	}
+
+	result, err := am.TestReceivers(ctx, apimodels.TestReceiversConfigBodyParams{
+		Alert: &apimodels.TestReceiversConfigAlertParams{
+			Labels: model.LabelSet{
+				model.AlertNameLabel: "GrafanaUpdateAvailable",
+			},
+			Annotations: model.LabelSet{
+				"summary":     model.LabelValue(fmt.Sprintf("Grafana %s is available", latestVersion)),
+				"description": model.LabelValue(fmt.Sprintf("This instance is running Grafana %s, the latest version is %s.", currentVersion, latestVersion)),
+			},
+		},
+		Receivers: []*apimodels.PostableApiReceiver{receiver},
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, r := range result.Receivers {
+		for _, c := range r.Configs {
+			if c.Error != nil {
+				return fmt.Errorf("failed to notify %q: %w", c.Name, c.Error)
+			}
+		}
+	}
+
+	return nil
+}
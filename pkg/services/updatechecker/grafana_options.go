@@ -0,0 +1,258 @@
+package updatechecker
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/infra/usagestats"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/ngalert"
+	"github.com/grafana/grafana/pkg/services/supportbundles"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// Option configures a GrafanaService built by New.
+type Option func(*options)
+
+type options struct {
+	kvStore         kvstore.KVStore
+	registerer      prometheus.Registerer
+	alertNG         *ngalert.AlertNG
+	bundleRegistry  supportbundles.Service
+	usageStats      usagestats.Service
+	features        featuremgmt.FeatureToggles
+	resultStore     ResultStore
+	httpClient      httpClient
+	clock           clock.Clock
+	interval        time.Duration
+	urls            []string
+	versionProvider func() string
+}
+
+// WithKVStore persists the snooze, the update status and the instance ID in
+// kvStore.
+func WithKVStore(kvStore kvstore.KVStore) Option {
+	return func(o *options) { o.kvStore = kvStore }
+}
+
+// WithRegisterer registers the metrics with reg.
+func WithRegisterer(reg prometheus.Registerer) Option {
+	return func(o *options) { o.registerer = reg }
+}
+
+// WithAlertNG sends notifications about new versions through alerting, when
+// they're configured.
+func WithAlertNG(alertNG *ngalert.AlertNG) Option {
+	return func(o *options) { o.alertNG = alertNG }
+}
+
+// WithSupportBundles adds the checker's state to support bundles.
+func WithSupportBundles(bundleRegistry supportbundles.Service) Option {
+	return func(o *options) { o.bundleRegistry = bundleRegistry }
+}
+
+// WithUsageStats reports how far behind the instance is in usage stats, when
+// they're enabled.
+func WithUsageStats(usageStats usagestats.Service) Option {
+	return func(o *options) { o.usageStats = usageStats }
+}
+
+// WithFeatures sets the feature toggles that gate experimental behavior.
+func WithFeatures(features featuremgmt.FeatureToggles) Option {
+	return func(o *options) { o.features = features }
+}
+
+// WithResultStore persists the update status in store instead of the kvstore.
+func WithResultStore(store ResultStore) Option {
+	return func(o *options) { o.resultStore = store }
+}
+
+// WithHTTPClient requests update servers with client instead of a client
+// built from the configuration.
+func WithHTTPClient(client httpClient) Option {
+	return func(o *options) { o.httpClient = client }
+}
+
+// WithClock replaces the wall clock, e.g. with a mock in tests.
+func WithClock(c clock.Clock) Option {
+	return func(o *options) { o.clock = c }
+}
+
+// WithInterval sets the interval between checks, see SetInterval.
+func WithInterval(d time.Duration) Option {
+	return func(o *options) { o.interval = d }
+}
+
+// WithURLs sets the update servers, overriding the configured ones.
+func WithURLs(urls []string) Option {
+	return func(o *options) { o.urls = urls }
+}
+
+// WithVersionProvider sets the provider of the running version, see
+// SetVersionProvider.
+func WithVersionProvider(fn func() string) Option {
+	return func(o *options) { o.versionProvider = fn }
+}
+
+// New builds a GrafanaService from the configuration, with dependencies and
+// overrides passed as options. Without a registerer the metrics aren't
+// exposed, and without a kvstore nothing is persisted.
+func New(cfg *setting.Cfg, tracer tracing.Tracer, opts ...Option) *GrafanaService {
+	o := options{
+		registerer: prometheus.NewRegistry(),
+		urls:       cfg.UpdateCheckerURLs,
+		clock:      clock.New(),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.httpClient == nil {
+		o.httpClient = newGrafanaHTTPClient(cfg)
+	}
+
+	s := &GrafanaService{
+		enabled:              cfg.CheckForGrafanaUpdates,
+		grafanaVersion:       cfg.BuildVersion,
+		edition:              grafanaEdition(cfg.IsEnterprise),
+		parsedGrafanaVersion: parseVersion(cfg.BuildVersion),
+		deploymentChannel:    cfg.DeploymentChannel,
+		deploymentType:       detectDeploymentType(cfg.Packaging, hostSignals),
+		bannerSuppressed:     strings.EqualFold(cfg.DeploymentMode, DeploymentModeManaged),
+		nagInterval:          cfg.UpdateNagInterval,
+		ignoreTesting:        cfg.UpdateCheckerIgnoreTesting,
+		channelLadder:        cfg.UpdateCheckerChannelLadder,
+		notifyAboutBetas:     cfg.NotifyAboutBetas,
+		comparison:           cfg.UpdateCheckerComparison,
+		versionComponents:    cfg.UpdateCheckerVersionComponents,
+		rebuildsAsUpdates:    cfg.UpdateCheckerRebuildsAsUpdates,
+		buildCommit:          cfg.BuildCommit,
+		buildStamp:           buildStamp(cfg.BuildStamp),
+		method:               strings.ToUpper(cfg.UpdateCheckerMethod),
+		payloadKey:           cfg.UpdateCheckerPayloadKey,
+		digestSuffix:         cfg.UpdateCheckerDigestSuffix,
+		artifactURLTemplate:  cfg.UpdateCheckerArtifactURL,
+		defaultChangelogURL:  cfg.UpdateCheckerChangelogURL,
+		approvedVersions:     cfg.UpdateCheckerApprovedVersions,
+		maxPages:             cfg.UpdateCheckerMaxPages,
+		clockSkewTolerance:   cfg.UpdateCheckerClockSkewTolerance,
+		staleThreshold:       cfg.UpdateCheckerStaleThreshold,
+		maxMajorDistance:     cfg.UpdateCheckerMaxMajorDistance,
+		maxTestingLead:       cfg.UpdateCheckerMaxTestingLead,
+		interval:             defaultCheckInterval,
+		recheck:              make(chan struct{}, 1),
+		maxBackoff:           cfg.UpdateCheckerMaxBackoff,
+		alignChecks:          cfg.UpdateCheckerAlignChecks,
+		maxChecks:            cfg.UpdateCheckerMaxChecks,
+		heartbeatInterval:    cfg.UpdateCheckerHeartbeatInterval,
+		traceConnections:     cfg.UpdateCheckerTraceConnections,
+		sendInstanceID:       cfg.UpdateCheckerSendInstanceID,
+		region:               cfg.UpdateCheckerRegion,
+		timeouts:             requestTimeouts(cfg),
+		testingOverrides:     cfg.Env == setting.Dev,
+		assertInvariants:     cfg.Env == setting.Dev,
+		versionProvider:      o.versionProvider,
+		mirrors:              newMirrorStatuses(o.urls),
+		httpClient:           o.httpClient,
+		resultStore:          o.resultStore,
+		tracer:               tracer,
+		metrics:              newGrafanaMetrics(o.registerer, cfg.BuildVersion, grafanaEdition(cfg.IsEnterprise)),
+		clock:                o.clock,
+		log:                  log.New("grafana.update.checker"),
+	}
+
+	s.webhookCtx, s.stopWebhooks = context.WithCancel(context.Background())
+
+	if o.kvStore != nil {
+		s.kvStore = kvstore.WithNamespace(o.kvStore, 0, "updatechecker.grafana")
+		if s.resultStore == nil {
+			s.resultStore = ProvideKVResultStore(o.kvStore)
+		}
+	}
+	if o.interval != 0 {
+		s.SetInterval(o.interval)
+	}
+
+	if s.enabled && isDevelopmentVersion(s.grafanaVersion) {
+		s.log.Info("Disabling Grafana update checks, comparing a development build to a release is meaningless", "version", s.grafanaVersion)
+		s.enabled = false
+	}
+	if marker, ok := detectEphemeral(hostSignals); s.enabled && cfg.UpdateCheckerSuppressInEphemeral && ok {
+		s.log.Warn("Disabling Grafana update checks on an ephemeral instance, set suppress_in_ephemeral = false to check anyway", "marker", marker)
+		s.enabled = false
+	}
+
+	if _, ok := deploymentChannels[strings.ToLower(s.deploymentChannel)]; s.deploymentChannel != "" && !ok {
+		s.log.Warn("Unknown deployment channel, falling back to the channel of the running version", "deploymentChannel", s.deploymentChannel)
+	}
+
+	if cfg.DeploymentMode != "" && !s.bannerSuppressed {
+		s.log.Warn("Unknown deployment mode, showing the update banner", "deploymentMode", cfg.DeploymentMode)
+	}
+
+	if s.comparison != ComparisonFull && s.comparison != ComparisonMinor && s.comparison != ComparisonServer {
+		s.log.Warn("Unknown update comparison, falling back to full", "comparison", s.comparison)
+		s.comparison = ComparisonFull
+	}
+
+	activeHours, err := parseActiveHours(cfg.UpdateCheckerActiveHours)
+	if err != nil {
+		s.log.Warn("Invalid update check active hours, checking at all hours", "activeHours", cfg.UpdateCheckerActiveHours, "error", err)
+	}
+	s.activeHours = activeHours
+
+	if s.method != http.MethodGet && s.method != http.MethodPost {
+		s.log.Warn("Unknown update check method, falling back to GET", "method", cfg.UpdateCheckerMethod)
+		s.method = http.MethodGet
+	}
+
+	if !ipFamilies[cfg.UpdateCheckerIPFamily] {
+		s.log.Warn("Unknown update check IP family, falling back to tcp", "ipFamily", cfg.UpdateCheckerIPFamily)
+	}
+
+	if s.traceConnections {
+		s.metrics.registerConnectionMetrics(o.registerer)
+	}
+	s.metrics.enabled.Set(boolToFloat64(s.enabled))
+
+	for name, err := range s.metrics.registrationErrors {
+		s.log.Error("Failed to register update checker metric", "metric", name, "error", err)
+	}
+	s.log.Debug("Registered update checker metrics", "metrics", s.metrics.registered)
+
+	if cfg.UpdateCheckerNotificationContactPoint != "" && isFeatureEnabled(o.features, featuremgmt.FlagUpdateCheckerNotifications) {
+		s.notifier = &contactPointNotifier{
+			alertNG:      o.alertNG,
+			orgID:        cfg.UpdateCheckerNotificationOrgID,
+			contactPoint: cfg.UpdateCheckerNotificationContactPoint,
+		}
+	}
+
+	if cfg.UpdateCheckerWebhookURL != "" {
+		s.webhook = &webhookNotifier{
+			url:        cfg.UpdateCheckerWebhookURL,
+			secret:     cfg.UpdateCheckerWebhookSecret,
+			retryDelay: time.Second,
+			httpClient: newWebhookHTTPClient(s.timeouts),
+			clock:      s.clock,
+			log:        s.log,
+		}
+	}
+
+	if o.bundleRegistry != nil {
+		o.bundleRegistry.RegisterSupportItemCollector(s.supportBundleCollector())
+	}
+	// Only instances opted into usage stats report how far behind they are.
+	if cfg.ReportingEnabled && o.usageStats != nil {
+		o.usageStats.RegisterMetricsFunc(s.usageStats)
+	}
+
+	return s
+}
@@ -0,0 +1,60 @@
+package updatechecker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("applies the options", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.CheckForGrafanaUpdates = true
+		cfg.BuildVersion = "9.3.0"
+
+		client := &scriptedHTTPClient{responses: []scriptedResponse{{body: `{"stable": "9.4.0"}`}}}
+		mock := clock.NewMock()
+		store := &memoryResultStore{}
+		svc := New(cfg, tracing.InitializeTracerForTest(),
+			WithHTTPClient(client),
+			WithClock(mock),
+			WithInterval(2*time.Hour),
+			WithURLs([]string{"https://mirror.example.com/latest.json"}),
+			WithResultStore(store),
+			WithVersionProvider(func() string { return "9.3.2" }),
+		)
+
+		require.Same(t, mock, svc.clock)
+		require.Equal(t, 2*time.Hour, svc.Interval())
+		require.Equal(t, []MirrorStatus{{URL: "https://mirror.example.com/latest.json"}}, svc.mirrors)
+
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.Equal(t, 1, client.requestCount())
+		require.True(t, svc.UpdateAvailable())
+		require.Equal(t, "9.3.2", svc.grafanaVersion)
+		require.Equal(t, 1, store.saveCount())
+	})
+
+	t.Run("works without optional dependencies", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.CheckForGrafanaUpdates = true
+		cfg.BuildVersion = "9.3.0"
+		cfg.UpdateCheckerSendInstanceID = true
+
+		client := &fakeHTTPClient{fakeResp: `{"stable": "9.3.1"}`}
+		svc := New(cfg, tracing.InitializeTracerForTest(), WithHTTPClient(client))
+		require.Nil(t, svc.kvStore)
+		require.Nil(t, svc.resultStore)
+		require.Equal(t, defaultCheckInterval, svc.Interval())
+
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.True(t, svc.UpdateAvailable())
+		require.Empty(t, svc.instanceID(context.Background()))
+	})
+}
@@ -0,0 +1,93 @@
+package updatechecker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/hashicorp/go-version"
+
+	"github.com/grafana/grafana/pkg/infra/tracing"
+)
+
+// fetchRemainingPages follows the next links of a paginated release index,
+// merging the releases of every page into latest. It stops as soon as the
+// fetched pages show whether a newer version exists, or after maxPages pages.
+func (s *GrafanaService) fetchRemainingPages(ctx context.Context, span tracing.Span, pageURL string, latest *latestJSON) error {
+	for pages := 1; latest.Next != "" && pages < s.maxPages; pages++ {
+		if s.knowsNewerVersion(latest) {
+			return nil
+		}
+
+		nextURL, err := resolvePageURL(pageURL, latest.Next)
+		if err != nil {
+			return err
+		}
+
+		fetched, _, err := s.fetchFrom(ctx, span, nextURL)
+		if err != nil {
+			return fmt.Errorf("failed to get page %d of the release index: %w", pages+1, err)
+		}
+		if fetched.err != nil {
+			return fmt.Errorf("failed to unmarshal page %d of the release index: %w", pages+1, fetched.err)
+		}
+		page := fetched.latest
+
+		latest.Releases = append(latest.Releases, page.Releases...)
+		latest.Next = page.Next
+		pageURL = nextURL
+	}
+
+	return nil
+}
+
+// knowsNewerVersion reports whether latest already answers the update question
+// for the running version, either through an explicit channel version or a
+// listed release newer than the running one.
+func (s *GrafanaService) knowsNewerVersion(latest *latestJSON) bool {
+	preRelease := s.releaseChannel() == channelTesting
+	if (preRelease && latest.Testing != "") || (!preRelease && latest.Stable != "") {
+		return true
+	}
+
+	_, currVersion := s.runningVersion()
+	if currVersion == nil {
+		return false
+	}
+
+	for _, r := range latest.Releases {
+		v, err := version.NewVersion(r)
+		if err != nil || (!preRelease && v.Prerelease() != "") {
+			continue
+		}
+		if currVersion.LessThan(v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// resolvePageURL resolves the next link of a page against the URL of that
+// page. Like redirects, see checkLatestRedirect, links are only followed on the
+// same host and never from HTTPS to HTTP.
+func resolvePageURL(pageURL, next string) (string, error) {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(next)
+	if err != nil {
+		return "", fmt.Errorf("invalid next page link %q: %w", next, err)
+	}
+
+	resolved := base.ResolveReference(ref)
+	if resolved.Host != base.Host {
+		return "", fmt.Errorf("refusing to follow next page link from %s to another host %s", base.Host, resolved.Host)
+	}
+	if base.Scheme == "https" && resolved.Scheme != "https" {
+		return "", errors.New("refusing to follow next page link from HTTPS to HTTP")
+	}
+	return resolved.String(), nil
+}
@@ -0,0 +1,118 @@
+package updatechecker
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrafanaUpdateChecker_paginatedIndex(t *testing.T) {
+	t.Run("stops once a newer version is found", func(t *testing.T) {
+		client := &routingHTTPClient{routes: map[string]routedResponse{
+			"https://releases.example.com/index": {
+				statusCode: http.StatusOK,
+				body:       `{"releases": ["9.2.0", "9.3.0-beta1"], "next": "https://releases.example.com/index?page=2"}`,
+			},
+			"https://releases.example.com/index?page=2": {
+				statusCode: http.StatusOK,
+				body:       `{"releases": ["9.3.1", "9.4.0-beta1"], "next": "?page=3"}`,
+			},
+			"https://releases.example.com/index?page=3": {
+				statusCode: http.StatusOK,
+				body:       `{"releases": ["9.4.0"]}`,
+			},
+		}}
+		svc := newTestGrafanaService("9.3.0", client)
+		svc.mirrors = newMirrorStatuses([]string{"https://releases.example.com/index"})
+
+		_, err := svc.checkForUpdates(context.Background())
+		require.NoError(t, err)
+		require.True(t, svc.UpdateAvailable())
+		require.Equal(t, "9.3.1", svc.LatestVersion())
+		require.Equal(t, []string{
+			"https://releases.example.com/index",
+			"https://releases.example.com/index?page=2",
+		}, client.requested)
+	})
+
+	t.Run("does not paginate when the channel version is known", func(t *testing.T) {
+		client := &routingHTTPClient{routes: map[string]routedResponse{
+			defaultLatestJSONURL: {
+				statusCode: http.StatusOK,
+				body:       `{"stable": "9.3.0", "next": "https://releases.example.com/index?page=2"}`,
+			},
+		}}
+		svc := newTestGrafanaService("9.3.0", client)
+
+		_, err := svc.checkForUpdates(context.Background())
+		require.NoError(t, err)
+		require.False(t, svc.UpdateAvailable())
+		require.Len(t, client.requested, 1)
+	})
+
+	t.Run("follows relative links and caps the number of pages", func(t *testing.T) {
+		client := &routingHTTPClient{routes: map[string]routedResponse{
+			"https://releases.example.com/index": {
+				statusCode: http.StatusOK,
+				body:       `{"releases": ["9.1.0"], "next": "/index?page=2"}`,
+			},
+			"https://releases.example.com/index?page=2": {
+				statusCode: http.StatusOK,
+				body:       `{"releases": ["9.2.0"], "next": "/index?page=3"}`,
+			},
+			"https://releases.example.com/index?page=3": {
+				statusCode: http.StatusOK,
+				body:       `{"releases": ["9.3.1"]}`,
+			},
+		}}
+		svc := newTestGrafanaService("9.3.0", client)
+		svc.mirrors = newMirrorStatuses([]string{"https://releases.example.com/index"})
+		svc.maxPages = 2
+
+		_, err := svc.checkForUpdates(context.Background())
+		require.NoError(t, err)
+		require.False(t, svc.UpdateAvailable())
+		require.Equal(t, "9.2.0", svc.LatestVersion())
+		require.Len(t, client.requested, 2)
+	})
+
+	t.Run("failing page fails the check", func(t *testing.T) {
+		client := &routingHTTPClient{routes: map[string]routedResponse{
+			"https://releases.example.com/index": {
+				statusCode: http.StatusOK,
+				body:       `{"releases": ["9.2.0"], "next": "https://releases.example.com/index?page=2"}`,
+			},
+			"https://releases.example.com/index?page=2": {statusCode: http.StatusBadGateway},
+		}}
+		svc := newTestGrafanaService("9.3.0", client)
+		svc.mirrors = newMirrorStatuses([]string{"https://releases.example.com/index"})
+
+		_, err := svc.checkForUpdates(context.Background())
+		require.ErrorContains(t, err, "page 2")
+		require.False(t, svc.HasCheckedSuccessfully())
+	})
+
+	for name, next := range map[string]string{
+		"links to another host aren't followed":    "https://evil.example.com/index?page=2",
+		"links from HTTPS to HTTP aren't followed": "http://releases.example.com/index?page=2",
+	} {
+		t.Run(name, func(t *testing.T) {
+			client := &routingHTTPClient{routes: map[string]routedResponse{
+				"https://releases.example.com/index": {
+					statusCode: http.StatusOK,
+					body:       `{"releases": ["9.2.0"], "next": "` + next + `"}`,
+				},
+				next: {statusCode: http.StatusOK, body: `{"releases": ["9.3.1"]}`},
+			}}
+			svc := newTestGrafanaService("9.3.0", client)
+			svc.mirrors = newMirrorStatuses([]string{"https://releases.example.com/index"})
+
+			_, err := svc.checkForUpdates(context.Background())
+			require.ErrorContains(t, err, "refusing to follow next page link")
+			require.Len(t, client.requested, 1)
+			require.False(t, svc.HasCheckedSuccessfully())
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package updatechecker
+
+import (
+	"encoding/json"
+)
+
+// Payload is a latest.json as read by the last successful check, after
+// following pagination and applying platform specific versions, for consumers
+// of fields the getters don't cover.
+type Payload struct {
+	Stable      string `json:"stable"`
+	Testing     string `json:"testing"`
+	Security    bool   `json:"security"`
+	Recommended string `json:"recommended"`
+	// Releases lists the released versions of paginated release indices.
+	Releases []string `json:"releases"`
+	// ReleaseDates maps versions to the date they were released, either as
+	// RFC 3339 timestamps or as YYYY-MM-DD dates.
+	ReleaseDates map[string]string `json:"releaseDates"`
+	// UpdateAvailable is set by update servers that decide whether the
+	// requesting instance should update.
+	UpdateAvailable *bool            `json:"updateAvailable"`
+	Builds          map[string]Build `json:"builds"`
+	// GeneratedAt is when the update server last refreshed its data, as an
+	// RFC 3339 timestamp.
+	GeneratedAt          string          `json:"generatedAt"`
+	Vulnerable           []Vulnerability `json:"vulnerable"`
+	Supported            []string        `json:"supported"`
+	ChangelogURLTemplate string          `json:"changelogURLTemplate"`
+	Approved             []string        `json:"approved"`
+	EOL                  []string        `json:"eol"`
+	// EOLDates maps release lines, e.g. "9.x" or "9.4.x", to when they reach
+	// their end of life, in the same formats as ReleaseDates.
+	EOLDates          map[string]string   `json:"eolDates"`
+	RequiresMigration map[string]bool     `json:"requiresMigration"`
+	UpgradePath       map[string][]string `json:"upgradePath"`
+	// Platforms holds versions for distributions with staggered releases,
+	// keyed by "GOOS/GOARCH" or just "GOOS".
+	Platforms map[string]PlatformVersions `json:"platforms"`
+}
+
+// Build describes the latest build of a version, which changes when the
+// version is rebuilt without a version bump.
+type Build struct {
+	Commit    string `json:"commit"`
+	Timestamp string `json:"timestamp"`
+}
+
+// Vulnerability is a known vulnerability and the range of versions it
+// affects, as a go-version constraint.
+type Vulnerability struct {
+	CVE      string `json:"cve"`
+	Versions string `json:"versions"`
+}
+
+// PlatformVersions are the versions of a distribution with staggered
+// releases.
+type PlatformVersions struct {
+	Stable  string `json:"stable"`
+	Testing string `json:"testing"`
+}
+
+// newPayload converts latest to a Payload, sharing its maps and slices.
+func newPayload(latest latestJSON) Payload {
+	p := Payload{
+		Stable:               latest.Stable,
+		Testing:              latest.Testing,
+		Security:             latest.Security,
+		Recommended:          latest.Recommended,
+		Releases:             latest.Releases,
+		ReleaseDates:         latest.ReleaseDates,
+		UpdateAvailable:      latest.UpdateAvailable,
+		GeneratedAt:          latest.GeneratedAt,
+		Supported:            latest.Supported,
+		ChangelogURLTemplate: latest.ChangelogURLTemplate,
+		Approved:             latest.Approved,
+		EOL:                  latest.EOL,
+		EOLDates:             latest.EOLDates,
+		RequiresMigration:    latest.RequiresMigration,
+		UpgradePath:          latest.UpgradePath,
+	}
+	if latest.Builds != nil {
+		p.Builds = make(map[string]Build, len(latest.Builds))
+		for v, b := range latest.Builds {
+			p.Builds[v] = Build(b)
+		}
+	}
+	if latest.Vulnerable != nil {
+		p.Vulnerable = make([]Vulnerability, len(latest.Vulnerable))
+		for i, v := range latest.Vulnerable {
+			p.Vulnerable[i] = Vulnerability(v)
+		}
+	}
+	if latest.Platforms != nil {
+		p.Platforms = make(map[string]PlatformVersions, len(latest.Platforms))
+		for platform, versions := range latest.Platforms {
+			p.Platforms[platform] = PlatformVersions(versions)
+		}
+	}
+	return p
+}
+
+// LastPayload returns a copy of the payload of the last successful check. It
+// returns false before any check succeeded.
+func (s *GrafanaService) LastPayload() (Payload, bool) {
+	s.mutex.RLock()
+	encoded := s.lastParsedPayload
+	s.mutex.RUnlock()
+	if encoded == nil {
+		return Payload{}, false
+	}
+
+	// Decoding a fresh copy keeps callers from sharing maps and slices with
+	// the checker.
+	var latest latestJSON
+	if err := json.Unmarshal(encoded, &latest); err != nil {
+		s.log.Warn("Failed to copy the last latest.json", "error", err)
+		return Payload{}, false
+	}
+	return newPayload(latest), true
+}
+
+// encodePayload encodes latest for LastPayload, returning nil if it can't be.
+func (s *GrafanaService) encodePayload(latest latestJSON) []byte {
+	encoded, err := json.Marshal(latest)
+	if err != nil {
+		s.log.Warn("Failed to encode latest.json", "error", err)
+		return nil
+	}
+	return encoded
+}
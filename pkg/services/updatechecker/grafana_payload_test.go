@@ -0,0 +1,64 @@
+package updatechecker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrafanaUpdateChecker_LastPayload(t *testing.T) {
+	client := &fakeHTTPClient{fakeResp: `{
+		"stable": "9.4.0",
+		"testing": "10.0.0-beta1",
+		"security": true,
+		"releaseDates": {"9.4.0": "2023-02-28"},
+		"vulnerable": [{"cve": "CVE-2023-1387", "versions": "< 9.4.0"}],
+		"eol": ["8.x"],
+		"builds": {"9.4.0": {"commit": "abc123"}},
+		"platforms": {"plan9/arm": {"stable": "9.3.6"}}
+	}`}
+	svc := newTestGrafanaService("9.3.0", client)
+
+	_, ok := svc.LastPayload()
+	require.False(t, ok)
+
+	require.NoError(t, svc.CheckNow(context.Background()))
+	payload, ok := svc.LastPayload()
+	require.True(t, ok)
+	require.Equal(t, Payload{
+		Stable:       "9.4.0",
+		Testing:      "10.0.0-beta1",
+		Security:     true,
+		ReleaseDates: map[string]string{"9.4.0": "2023-02-28"},
+		Vulnerable:   []Vulnerability{{CVE: "CVE-2023-1387", Versions: "< 9.4.0"}},
+		EOL:          []string{"8.x"},
+		Builds:       map[string]Build{"9.4.0": {Commit: "abc123"}},
+		Platforms:    map[string]PlatformVersions{"plan9/arm": {Stable: "9.3.6"}},
+	}, payload)
+
+	t.Run("is a copy", func(t *testing.T) {
+		payload.ReleaseDates["9.4.0"] = "tomorrow"
+		payload.EOL[0] = "9.x"
+
+		again, ok := svc.LastPayload()
+		require.True(t, ok)
+		require.Equal(t, "2023-02-28", again.ReleaseDates["9.4.0"])
+		require.Equal(t, []string{"8.x"}, again.EOL)
+	})
+
+	t.Run("is kept when a check fails", func(t *testing.T) {
+		client.fakeResp = `not json`
+		require.Error(t, svc.CheckNow(context.Background()))
+
+		again, ok := svc.LastPayload()
+		require.True(t, ok)
+		require.Equal(t, "9.4.0", again.Stable)
+
+		client.fakeResp = `{"stable": "9.4.1"}`
+		require.NoError(t, svc.CheckNow(context.Background()))
+		again, ok = svc.LastPayload()
+		require.True(t, ok)
+		require.Equal(t, Payload{Stable: "9.4.1"}, again)
+	})
+}
@@ -0,0 +1,54 @@
+package updatechecker
+
+import (
+	"fmt"
+)
+
+// checkPlausible returns an error if a version of latest is more major
+// versions away from the running one than configured, e.g. a mirror running
+// 10.x being served the latest.json of another product at 2.x. It can't tell
+// without a parsable running version.
+func (s *GrafanaService) checkPlausible(latest latestJSON) error {
+	_, curr := s.runningVersion()
+	if s.maxMajorDistance <= 0 || curr == nil {
+		return nil
+	}
+
+	currMajor := curr.Segments()[0]
+	for _, v := range []string{latest.Stable, latest.Testing} {
+		parsed := parseVersion(v)
+		if parsed == nil {
+			continue
+		}
+
+		distance := parsed.Segments()[0] - currMajor
+		if distance < 0 {
+			distance = -distance
+		}
+		if distance > s.maxMajorDistance {
+			return fmt.Errorf("version %s is %d major versions away from the running version %s", v, distance, curr)
+		}
+	}
+	return nil
+}
+
+// dropAnomalousTesting replaces a testing version that's more major versions
+// ahead of the stable one than configured with the stable version, as such a
+// jump is more likely a mistake of the update server than a release. Testing
+// builds then compare against stable, and stable builds see no newer testing
+// release.
+func (s *GrafanaService) dropAnomalousTesting(url string, latest *latestJSON) {
+	if s.maxTestingLead <= 0 {
+		return
+	}
+	stable, testing := parseVersion(latest.Stable), parseVersion(latest.Testing)
+	if stable == nil || testing == nil {
+		return
+	}
+
+	lead := testing.Segments()[0] - stable.Segments()[0]
+	if lead > s.maxTestingLead {
+		s.log.Error("Update server testing version is implausibly far ahead of stable, ignoring it", "url", url, "testing", latest.Testing, "stable", latest.Stable, "maxLead", s.maxTestingLead)
+		latest.Testing = latest.Stable
+	}
+}
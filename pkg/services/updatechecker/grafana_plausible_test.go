@@ -0,0 +1,91 @@
+package updatechecker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log/logtest"
+)
+
+func TestGrafanaUpdateChecker_implausibleVersions(t *testing.T) {
+	tests := []struct {
+		name      string
+		running   string
+		payload   string
+		plausible bool
+	}{
+		{name: "next major", running: "10.0.0", payload: `{"stable": "11.0.0"}`, plausible: true},
+		{name: "within tolerance", running: "9.3.0", payload: `{"stable": "11.0.0", "testing": "11.1.0-beta1"}`, plausible: true},
+		{name: "another product", running: "10.0.0", payload: `{"stable": "2.1.0"}`},
+		{name: "implausible testing", running: "10.0.0", payload: `{"stable": "10.0.1", "testing": "42.0.0-beta1"}`},
+		{name: "unparsable running version", running: "main", payload: `{"stable": "2.1.0"}`, plausible: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestGrafanaService(tt.running, &fakeHTTPClient{fakeResp: tt.payload})
+			svc.maxMajorDistance = 2
+
+			err := svc.CheckNow(context.Background())
+			if tt.plausible {
+				require.NoError(t, err)
+				require.True(t, svc.HasCheckedSuccessfully())
+				return
+			}
+			require.ErrorContains(t, err, "implausible latest.json")
+			require.False(t, svc.HasCheckedSuccessfully())
+			require.False(t, svc.UpdateAvailable())
+		})
+	}
+
+	t.Run("disabled with a distance of 0", func(t *testing.T) {
+		svc := newTestGrafanaService("10.0.0", &fakeHTTPClient{fakeResp: `{"stable": "2.1.0"}`})
+		require.NoError(t, svc.CheckNow(context.Background()))
+	})
+}
+
+func TestGrafanaUpdateChecker_testingLead(t *testing.T) {
+	tests := []struct {
+		name      string
+		running   string
+		payload   string
+		anomalous bool
+		latest    string
+		hasUpdate bool
+	}{
+		{name: "testing build within the lead", running: "10.0.0-beta1", payload: `{"stable": "9.5.2", "testing": "10.0.0-beta2"}`, latest: "10.0.0-beta2", hasUpdate: true},
+		{name: "testing build beyond the lead compares against stable", running: "9.5.0-beta1", payload: `{"stable": "9.5.2", "testing": "12.0.0-beta1"}`, anomalous: true, latest: "9.5.2", hasUpdate: true},
+		{name: "stable build beyond the lead", running: "9.5.2", payload: `{"stable": "9.5.2", "testing": "11.0.0-beta1"}`, anomalous: true, latest: "9.5.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &logtest.Fake{}
+			svc := newTestGrafanaService(tt.running, &fakeHTTPClient{fakeResp: tt.payload})
+			svc.log = logger
+			svc.maxTestingLead = 1
+
+			require.NoError(t, svc.CheckNow(context.Background()))
+			require.Equal(t, tt.latest, svc.LatestVersion())
+			require.Equal(t, tt.hasUpdate, svc.UpdateAvailable())
+			_, hasPreview := svc.PreviewVersion()
+			require.False(t, hasPreview)
+			if tt.anomalous {
+				require.Equal(t, 1, logger.ErrorLogs.Calls)
+				require.Equal(t, "Update server testing version is implausibly far ahead of stable, ignoring it", logger.ErrorLogs.Message)
+			} else {
+				require.Zero(t, logger.ErrorLogs.Calls)
+			}
+		})
+	}
+
+	t.Run("disabled with a lead of 0", func(t *testing.T) {
+		svc := newTestGrafanaService("9.5.2", &fakeHTTPClient{fakeResp: `{"stable": "9.5.2", "testing": "11.0.0-beta1"}`})
+		require.NoError(t, svc.CheckNow(context.Background()))
+		preview, ok := svc.PreviewVersion()
+		require.True(t, ok)
+		require.Equal(t, "11.0.0-beta1", preview)
+	})
+}
@@ -0,0 +1,161 @@
+package updatechecker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/grafana/grafana/pkg/infra/tracing"
+)
+
+// DefaultProduct is the name under which Grafana itself is checked.
+const DefaultProduct = "grafana"
+
+// Product is a tool bundled with Grafana, e.g. by a packaged distribution,
+// whose updates are checked alongside those of Grafana.
+type Product struct {
+	Name string
+	// Version is the installed version of the product.
+	Version string
+	// URL serves the latest.json of the product.
+	URL string
+	// PayloadKey is the dot-separated key path the version info is nested
+	// under, like the payload_key setting for Grafana.
+	PayloadKey string
+}
+
+// productState is a registered product with the result of its last
+// successful check.
+type productState struct {
+	Product
+	latestVersion string
+	hasUpdate     bool
+}
+
+// RegisterProduct adds a product to check on every update check, with its own
+// version, update server and result. Registering a product again replaces it.
+func (s *GrafanaService) RegisterProduct(p Product) error {
+	switch {
+	case p.Name == "" || p.Name == DefaultProduct:
+		return fmt.Errorf("invalid product name %q", p.Name)
+	case p.URL == "":
+		return errors.New("product URL is missing")
+	case parseVersion(p.Version) == nil:
+		return fmt.Errorf("invalid %s version %q", p.Name, p.Version)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.products == nil {
+		s.products = map[string]*productState{}
+	}
+	s.products[p.Name] = &productState{Product: p}
+	return nil
+}
+
+// UpdateAvailableFor reports whether a newer version of the given product
+// exists, with DefaultProduct standing for Grafana itself. It's false for
+// products that aren't registered or haven't been checked successfully.
+func (s *GrafanaService) UpdateAvailableFor(product string) bool {
+	if product == DefaultProduct {
+		return s.UpdateAvailable()
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	p, ok := s.products[product]
+	return ok && p.hasUpdate
+}
+
+// LatestVersionFor returns the latest version of the given product, like
+// UpdateAvailableFor.
+func (s *GrafanaService) LatestVersionFor(product string) string {
+	if product == DefaultProduct {
+		return s.LatestVersion()
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if p, ok := s.products[product]; ok {
+		return p.latestVersion
+	}
+	return ""
+}
+
+// checkProducts checks every registered product, in name order. Products are
+// independent of each other and of Grafana, so a failed check keeps the last
+// result of that product only while the others are still recorded. The
+// failures are returned together.
+func (s *GrafanaService) checkProducts(ctx context.Context, span tracing.Span) error {
+	s.mutex.RLock()
+	products := make([]Product, 0, len(s.products))
+	for _, p := range s.products {
+		products = append(products, p.Product)
+	}
+	s.mutex.RUnlock()
+	sort.Slice(products, func(i, j int) bool { return products[i].Name < products[j].Name })
+
+	var errs error
+	for _, p := range products {
+		latestVersion, hasUpdate, err := s.checkProduct(ctx, span, p)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("%s: %w", p.Name, err))
+			continue
+		}
+
+		s.mutex.Lock()
+		// The product may have been replaced while it was being checked.
+		if state, ok := s.products[p.Name]; ok && state.Product == p {
+			state.latestVersion, state.hasUpdate = latestVersion, hasUpdate
+		}
+		s.mutex.Unlock()
+	}
+	return errs
+}
+
+func (s *GrafanaService) checkProduct(ctx context.Context, span tracing.Span, p Product) (string, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.URL, nil)
+	if err != nil {
+		return "", false, err
+	}
+	s.tracer.Inject(ctx, req.Header, span)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", false, err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			s.log.Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", false, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	payload, err := s.decodeResponse(resp, p.PayloadKey)
+	if err != nil {
+		return "", false, err
+	}
+	if payload.err != nil {
+		return "", false, fmt.Errorf("failed to unmarshal latest.json: %w", payload.err)
+	}
+	latest := payload.latest
+	if err := validate(latest); err != nil {
+		return "", false, fmt.Errorf("invalid latest.json: %w", err)
+	}
+
+	latestVersion := latest.Stable
+	if isPreRelease(p.Version) && latest.Testing != "" {
+		latestVersion = latest.Testing
+	}
+	curr, latestV := parseVersion(p.Version), parseVersion(latestVersion)
+	return latestVersion, curr != nil && latestV != nil && latestV.GreaterThan(curr), nil
+}
@@ -0,0 +1,105 @@
+package updatechecker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrafanaUpdateChecker_products(t *testing.T) {
+	const (
+		lokiURL  = "https://mirror.example.com/loki/latest.json"
+		tempoURL = "https://mirror.example.com/tempo/latest.json"
+	)
+
+	t.Run("products are checked independently", func(t *testing.T) {
+		client := &routingHTTPClient{routes: map[string]routedResponse{
+			defaultLatestJSONURL: {statusCode: http.StatusOK, body: `{"stable": "9.3.0"}`},
+			lokiURL:              {statusCode: http.StatusOK, body: `{"stable": "2.8.0"}`},
+			tempoURL:             {statusCode: http.StatusOK, body: `{"products": {"tempo": {"stable": "2.0.1"}}}`},
+		}}
+		svc := newTestGrafanaService("9.3.0", client)
+		require.NoError(t, svc.RegisterProduct(Product{Name: "loki", Version: "2.7.4", URL: lokiURL}))
+		require.NoError(t, svc.RegisterProduct(Product{Name: "tempo", Version: "2.0.1", URL: tempoURL, PayloadKey: "products.tempo"}))
+
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.False(t, svc.UpdateAvailableFor(DefaultProduct))
+		require.Equal(t, "9.3.0", svc.LatestVersionFor(DefaultProduct))
+		require.True(t, svc.UpdateAvailableFor("loki"))
+		require.Equal(t, "2.8.0", svc.LatestVersionFor("loki"))
+		require.False(t, svc.UpdateAvailableFor("tempo"))
+		require.Equal(t, "2.0.1", svc.LatestVersionFor("tempo"))
+
+		require.False(t, svc.UpdateAvailableFor("mimir"))
+		require.Empty(t, svc.LatestVersionFor("mimir"))
+	})
+
+	t.Run("a failing product keeps its last result", func(t *testing.T) {
+		client := &routingHTTPClient{routes: map[string]routedResponse{
+			defaultLatestJSONURL: {statusCode: http.StatusOK, body: `{"stable": "9.3.1"}`},
+			lokiURL:              {statusCode: http.StatusOK, body: `{"stable": "2.8.0"}`},
+		}}
+		svc := newTestGrafanaService("9.3.0", client)
+		require.NoError(t, svc.RegisterProduct(Product{Name: "loki", Version: "2.7.4", URL: lokiURL}))
+		require.NoError(t, svc.CheckNow(context.Background()))
+
+		client.routes[lokiURL] = routedResponse{err: errors.New("connection refused")}
+		client.routes[defaultLatestJSONURL] = routedResponse{statusCode: http.StatusOK, body: `{"stable": "9.3.2"}`}
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.Equal(t, "9.3.2", svc.LatestVersionFor(DefaultProduct))
+		require.True(t, svc.UpdateAvailableFor("loki"))
+		require.Equal(t, "2.8.0", svc.LatestVersionFor("loki"))
+	})
+
+	t.Run("products are checked while Grafana's check fails", func(t *testing.T) {
+		client := &routingHTTPClient{routes: map[string]routedResponse{
+			defaultLatestJSONURL: {statusCode: http.StatusBadGateway},
+			lokiURL:              {statusCode: http.StatusOK, body: `{"stable": "2.8.0"}`},
+		}}
+		svc := newTestGrafanaService("9.3.0", client)
+		require.NoError(t, svc.RegisterProduct(Product{Name: "loki", Version: "2.7.4", URL: lokiURL}))
+
+		require.Error(t, svc.CheckNow(context.Background()))
+		require.True(t, svc.UpdateAvailableFor("loki"))
+	})
+
+	t.Run("failures are collected while the other products are recorded", func(t *testing.T) {
+		const mimirURL = "https://mirror.example.com/mimir/latest.json"
+		client := &routingHTTPClient{routes: map[string]routedResponse{
+			lokiURL:  {err: errors.New("connection refused")},
+			mimirURL: {statusCode: http.StatusOK, body: `{"stable": "2.7.0"}`},
+			tempoURL: {statusCode: http.StatusNotFound},
+		}}
+		svc := newTestGrafanaService("9.3.0", client)
+		require.NoError(t, svc.RegisterProduct(Product{Name: "loki", Version: "2.7.4", URL: lokiURL}))
+		require.NoError(t, svc.RegisterProduct(Product{Name: "mimir", Version: "2.6.0", URL: mimirURL}))
+		require.NoError(t, svc.RegisterProduct(Product{Name: "tempo", Version: "2.0.1", URL: tempoURL}))
+
+		ctx, span := svc.tracer.Start(context.Background(), "test")
+		defer span.End()
+		err := svc.checkProducts(ctx, span)
+
+		var merr *multierror.Error
+		require.ErrorAs(t, err, &merr)
+		require.Len(t, merr.Errors, 2)
+		require.EqualError(t, merr.Errors[0], "loki: connection refused")
+		require.EqualError(t, merr.Errors[1], "tempo: unexpected status code 404")
+
+		require.True(t, svc.UpdateAvailableFor("mimir"))
+		require.Equal(t, "2.7.0", svc.LatestVersionFor("mimir"))
+		require.False(t, svc.UpdateAvailableFor("loki"))
+		require.False(t, svc.UpdateAvailableFor("tempo"))
+	})
+
+	t.Run("invalid products are rejected", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &routingHTTPClient{})
+		require.Error(t, svc.RegisterProduct(Product{Name: DefaultProduct, Version: "9.3.0", URL: lokiURL}))
+		require.Error(t, svc.RegisterProduct(Product{Version: "2.7.4", URL: lokiURL}))
+		require.Error(t, svc.RegisterProduct(Product{Name: "loki", Version: "2.7.4"}))
+		require.Error(t, svc.RegisterProduct(Product{Name: "loki", Version: "dev", URL: lokiURL}))
+	})
+}
@@ -0,0 +1,134 @@
+package updatechecker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/require"
+)
+
+// largeLatestJSON returns a release index listing n releases.
+func largeLatestJSON(stable string, n int) string {
+	releases := make([]string, n)
+	for i := range releases {
+		releases[i] = fmt.Sprintf(`"9.%d.%d"`, i/100, i%100)
+	}
+	return `{"stable": "` + stable + `", "releases": [` + strings.Join(releases, ", ") + `]}`
+}
+
+func TestGrafanaUpdateChecker_readBody(t *testing.T) {
+	t.Run("a later payload replaces the kept one", func(t *testing.T) {
+		first, second := largeLatestJSON("9.3.1", 2000), largeLatestJSON("9.4.0", 1000)
+		client := &routingHTTPClient{routes: map[string]routedResponse{
+			defaultLatestJSONURL: {statusCode: http.StatusOK, header: http.Header{"Etag": []string{`"first"`}}, body: first},
+		}}
+		svc := newTestGrafanaService("9.3.0", client)
+
+		_, err := svc.checkForUpdates(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "9.3.1", svc.LatestVersion())
+
+		client.routes[defaultLatestJSONURL] = routedResponse{statusCode: http.StatusOK, body: second}
+		_, err = svc.checkForUpdates(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "9.4.0", svc.LatestVersion())
+
+		svc.mutex.RLock()
+		defer svc.mutex.RUnlock()
+		require.Equal(t, second, string(svc.lastPayload[:len(second)]))
+	})
+
+	t.Run("keeps the payload cached by ETag intact", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{})
+		first := largeLatestJSON("9.3.1", 2000)
+
+		payload, err := svc.decodeResponse(&http.Response{Body: io.NopCloser(strings.NewReader(first))}, "")
+		require.NoError(t, err)
+		svc.cacheResponse(defaultLatestJSONURL, `"first"`, payload)
+
+		_, err = svc.decodeResponse(&http.Response{Body: io.NopCloser(strings.NewReader(largeLatestJSON("9.4.0", 2000)))}, "")
+		require.NoError(t, err)
+
+		cached, ok := svc.cachedResponse(defaultLatestJSONURL)
+		require.True(t, ok)
+		require.Equal(t, "9.3.1", cached.payload.latest.Stable)
+		require.Equal(t, first, string(cached.payload.head))
+	})
+
+	t.Run("rejects oversized responses", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{})
+
+		_, err := svc.readBody(&http.Response{Body: io.NopCloser(io.LimitReader(zeroReader{}, maxLatestJSONSize+1))})
+		require.ErrorContains(t, err, "response exceeds")
+	})
+}
+
+func TestGrafanaUpdateChecker_decodeResponse(t *testing.T) {
+	t.Run("decodes the payload as it's read", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{})
+		pr, pw := io.Pipe()
+		go func() {
+			for _, chunk := range []string{`{"products": {"grafana": `, `{"stable": "9.3.1"`, `}}}`} {
+				_, _ = pw.Write([]byte(chunk))
+			}
+			_ = pw.Close()
+		}()
+
+		payload, err := svc.decodeResponse(&http.Response{Body: pr}, "products.grafana")
+		require.NoError(t, err)
+		require.NoError(t, payload.err)
+		require.Equal(t, "9.3.1", payload.latest.Stable)
+		require.False(t, payload.truncated)
+	})
+
+	t.Run("keeps the start of long payloads", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{})
+		body := largeLatestJSON("9.3.1", 20000)
+
+		payload, err := svc.decodeResponse(&http.Response{Body: io.NopCloser(strings.NewReader(body))}, "")
+		require.NoError(t, err)
+		require.Len(t, payload.latest.Releases, 20000)
+		require.True(t, payload.truncated)
+		require.Equal(t, body[:maxSupportBundlePayloadSize], string(payload.head))
+	})
+
+	t.Run("reports malformed payloads on the payload", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{})
+
+		payload, err := svc.decodeResponse(&http.Response{Body: io.NopCloser(strings.NewReader(`{"stable": `))}, "")
+		require.NoError(t, err)
+		require.ErrorIs(t, payload.err, errTruncatedPayload)
+		require.Equal(t, `{"stable": `, string(payload.head))
+	})
+
+	t.Run("fails when the body can't be read", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{})
+		body := io.MultiReader(strings.NewReader(`{"stable": "9.3.1`), iotest.ErrReader(errors.New("connection reset")))
+
+		_, err := svc.decodeResponse(&http.Response{Body: io.NopCloser(body)}, "")
+		require.ErrorContains(t, err, "failed to read response: connection reset")
+	})
+
+	t.Run("rejects oversized responses", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{})
+		body := strings.NewReader(strings.Repeat(" ", maxLatestJSONSize) + `{"stable": "9.3.1"}`)
+
+		_, err := svc.decodeResponse(&http.Response{Body: io.NopCloser(body)}, "")
+		require.ErrorContains(t, err, "response exceeds")
+	})
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
@@ -0,0 +1,29 @@
+package updatechecker
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// maxRedirects matches the limit of the default redirect policy.
+const maxRedirects = 10
+
+// checkLatestRedirect lets update servers move latest.json, e.g. to a
+// versioned path such as /v2/latest.json, without breaking older instances.
+// Redirects are only followed on the same host and never from HTTPS to HTTP,
+// so that a mirror can't send checks, and the headers they carry, elsewhere.
+func checkLatestRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+
+	orig := via[0].URL
+	if req.URL.Host != orig.Host {
+		return fmt.Errorf("refusing to follow redirect from %s to another host %s", orig.Host, req.URL.Host)
+	}
+	if orig.Scheme == "https" && req.URL.Scheme != "https" {
+		return errors.New("refusing to follow redirect from HTTPS to HTTP")
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package updatechecker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestGrafanaUpdateChecker_redirects(t *testing.T) {
+	t.Run("follows a same-host redirect to a newer schema", func(t *testing.T) {
+		for _, status := range []int{http.StatusMovedPermanently, http.StatusFound} {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/latest.json", func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, "/v2/latest.json", status)
+			})
+			mux.HandleFunc("/v2/latest.json", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"schemaVersion": 2, "channels": {"stable": {"version": "9.3.1", "security": true}, "testing": {"version": "9.4.0-beta1"}}}`))
+			})
+			server := httptest.NewServer(mux)
+
+			svc := newTestGrafanaService("9.3.0", newGrafanaHTTPClient(setting.NewCfg()))
+			svc.mirrors = newMirrorStatuses([]string{server.URL + "/latest.json"})
+			require.NoError(t, svc.CheckNow(context.Background()))
+			server.Close()
+
+			require.True(t, svc.UpdateAvailable())
+			require.Equal(t, "9.3.1", svc.LatestVersion())
+			require.True(t, svc.Status().SecurityUpdate)
+		}
+	})
+
+	t.Run("doesn't follow redirects to other hosts", func(t *testing.T) {
+		other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Error("the redirect to another host was followed")
+		}))
+		defer other.Close()
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, other.URL+"/latest.json", http.StatusFound)
+		}))
+		defer server.Close()
+
+		svc := newTestGrafanaService("9.3.0", newGrafanaHTTPClient(setting.NewCfg()))
+		svc.mirrors = newMirrorStatuses([]string{server.URL + "/latest.json"})
+		require.ErrorContains(t, svc.CheckNow(context.Background()), "another host")
+	})
+
+	t.Run("redirect policy", func(t *testing.T) {
+		newRequest := func(url string) *http.Request {
+			req, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+			return req
+		}
+		via := []*http.Request{newRequest("https://mirror.example.com/latest.json")}
+
+		require.NoError(t, checkLatestRedirect(newRequest("https://mirror.example.com/v2/latest.json"), via))
+		require.Error(t, checkLatestRedirect(newRequest("https://evil.example.com/latest.json"), via))
+		require.Error(t, checkLatestRedirect(newRequest("http://mirror.example.com/v2/latest.json"), via))
+
+		for len(via) < maxRedirects {
+			via = append(via, newRequest("https://mirror.example.com/latest.json"))
+		}
+		require.Error(t, checkLatestRedirect(newRequest("https://mirror.example.com/v2/latest.json"), via))
+	})
+}
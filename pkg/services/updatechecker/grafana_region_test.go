@@ -0,0 +1,39 @@
+package updatechecker
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrafanaUpdateChecker_region(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		region   string
+		expected string
+	}{
+		{name: "not sent by default", url: "https://grafana.com/api/grafana/versions/latest", expected: "https://grafana.com/api/grafana/versions/latest"},
+		{name: "sent when configured", url: "https://grafana.com/api/grafana/versions/latest", region: "eu-west-1", expected: "https://grafana.com/api/grafana/versions/latest?region=eu-west-1"},
+		{name: "URL-encoded", url: "https://grafana.com/api/grafana/versions/latest", region: "us east/1&a=b", expected: "https://grafana.com/api/grafana/versions/latest?region=us+east%2F1%26a%3Db"},
+		{name: "keeps the query of the URL", url: "https://mirror.example.com/latest.json?channel=stable", region: "eu-west-1", expected: "https://mirror.example.com/latest.json?channel=stable&region=eu-west-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeHTTPClient{fakeResp: `{"stable": "9.3.1"}`}
+			svc := newTestGrafanaService("9.3.0", client)
+			svc.mirrors = newMirrorStatuses([]string{tt.url})
+			svc.region = tt.region
+
+			require.NoError(t, svc.CheckNow(context.Background()))
+			require.Equal(t, tt.expected, client.requestURL)
+
+			requested, err := url.Parse(client.requestURL)
+			require.NoError(t, err)
+			require.Equal(t, tt.region, requested.Query().Get(regionQueryParam))
+		})
+	}
+}
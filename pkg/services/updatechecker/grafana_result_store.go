@@ -0,0 +1,132 @@
+package updatechecker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+)
+
+const statusKey = "status"
+
+// UpdateStatus is the outcome of the last successful update check.
+type UpdateStatus struct {
+	CurrentVersion     string    `json:"currentVersion"`
+	LatestVersion      string    `json:"latestVersion"`
+	RecommendedVersion string    `json:"recommendedVersion"`
+	UpdateAvailable    bool      `json:"updateAvailable"`
+	SecurityUpdate     bool      `json:"securityUpdate"`
+	CheckedAt          time.Time `json:"checkedAt"`
+	// NotifiedVersion is the latest version users were notified about, so
+	// that they aren't notified about it again after a restart.
+	NotifiedVersion string `json:"notifiedVersion,omitempty"`
+	// WebhookVersion is the latest version the webhook was called for, for
+	// the same reason.
+	WebhookVersion string `json:"webhookVersion,omitempty"`
+}
+
+// ResultStore persists the update status, so that it survives restarts and
+// can be shared between the instances of a highly available setup.
+type ResultStore interface {
+	// Load returns the stored status, or nil if there is none.
+	Load(ctx context.Context) (*UpdateStatus, error)
+	Save(ctx context.Context, status *UpdateStatus) error
+}
+
+// KVResultStore is the default ResultStore, keeping the status in the
+// kvstore.
+type KVResultStore struct {
+	kv *kvstore.NamespacedKVStore
+}
+
+func ProvideKVResultStore(kv kvstore.KVStore) *KVResultStore {
+	return &KVResultStore{kv: kvstore.WithNamespace(kv, 0, "updatechecker.grafana")}
+}
+
+func (s *KVResultStore) Load(ctx context.Context) (*UpdateStatus, error) {
+	val, ok, err := s.kv.Get(ctx, statusKey)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	var status UpdateStatus
+	if err := json.Unmarshal([]byte(val), &status); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal update status: %w", err)
+	}
+	return &status, nil
+}
+
+func (s *KVResultStore) Save(ctx context.Context, status *UpdateStatus) error {
+	val, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	return s.kv.Set(ctx, statusKey, string(val))
+}
+
+// Status returns the outcome of the last successful update check.
+func (s *GrafanaService) Status() UpdateStatus {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return UpdateStatus{
+		CurrentVersion:     s.grafanaVersion,
+		LatestVersion:      s.latestVersion,
+		RecommendedVersion: s.recommendedVersion,
+		UpdateAvailable:    s.hasUpdate,
+		SecurityUpdate:     s.securityUpdate,
+		CheckedAt:          s.lastSuccessAt,
+		NotifiedVersion:    s.notifiedVersion,
+		WebhookVersion:     s.webhookVersion,
+	}
+}
+
+// loadStatus restores the status saved by a previous run, so that an update
+// is reported right away instead of after the first check. Whether an update
+// is available is recomputed, as the instance may have been upgraded since.
+func (s *GrafanaService) loadStatus(ctx context.Context) {
+	if s.resultStore == nil {
+		return
+	}
+
+	status, err := s.resultStore.Load(ctx)
+	if err != nil {
+		s.log.Warn("Failed to load update status", "error", err)
+		return
+	}
+	if status == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	s.latestVersion = status.LatestVersion
+	s.parsedLatestVersion = parseVersion(status.LatestVersion)
+	s.recommendedVersion = status.RecommendedVersion
+	s.securityUpdate = status.SecurityUpdate
+	s.lastSuccessAt = status.CheckedAt
+	s.notifiedVersion = status.NotifiedVersion
+	s.webhookVersion = status.WebhookVersion
+	if s.parsedGrafanaVersion != nil && s.parsedLatestVersion != nil {
+		s.hasUpdate = s.isNewer(s.parsedGrafanaVersion, s.parsedLatestVersion)
+	} else {
+		s.hasUpdate = status.UpdateAvailable && status.CurrentVersion == s.grafanaVersion
+	}
+	hasUpdate := s.hasUpdate
+	s.mutex.Unlock()
+
+	s.metrics.updateAvailable.Set(boolToFloat64(hasUpdate))
+}
+
+// saveStatus persists the status after a successful check.
+func (s *GrafanaService) saveStatus(ctx context.Context) {
+	if s.resultStore == nil {
+		return
+	}
+
+	status := s.Status()
+	if err := s.resultStore.Save(ctx, &status); err != nil {
+		s.log.Warn("Failed to save update status", "error", err)
+	}
+}
@@ -0,0 +1,132 @@
+package updatechecker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+)
+
+func TestGrafanaUpdateChecker_resultStore(t *testing.T) {
+	t.Run("loads the stored status on start", func(t *testing.T) {
+		store := &memoryResultStore{status: &UpdateStatus{
+			CurrentVersion:  "9.3.0",
+			LatestVersion:   "9.3.1",
+			UpdateAvailable: true,
+			CheckedAt:       time.Date(2023, time.March, 13, 10, 0, 0, 0, time.UTC),
+		}}
+		h := newRunHarness(t, "9.3.0", scriptedResponse{err: errors.New("connection refused")})
+		h.svc.resultStore = store
+
+		h.start()
+		h.requireState("9.3.1", true)
+		require.Equal(t, time.Date(2023, time.March, 13, 10, 0, 0, 0, time.UTC), h.svc.Status().CheckedAt)
+		require.Zero(t, store.saveCount())
+		require.ErrorIs(t, h.stop(), context.Canceled)
+	})
+
+	t.Run("saves the status after a successful check", func(t *testing.T) {
+		store := &memoryResultStore{}
+		h := newRunHarness(t, "9.3.0", scriptedResponse{body: `{"stable": "9.3.2", "security": true}`})
+		h.svc.resultStore = store
+
+		h.start()
+		require.ErrorIs(t, h.stop(), context.Canceled)
+
+		require.Equal(t, 1, store.saveCount())
+		require.Equal(t, &UpdateStatus{
+			CurrentVersion:  "9.3.0",
+			LatestVersion:   "9.3.2",
+			UpdateAvailable: true,
+			SecurityUpdate:  true,
+			CheckedAt:       h.clock.Now(),
+		}, store.status)
+	})
+
+	t.Run("doesn't notify about the same version again after a restart", func(t *testing.T) {
+		store := &memoryResultStore{}
+		notifier := &fakeNewVersionNotifier{}
+		h := newRunHarness(t, "9.3.0", scriptedResponse{body: `{"stable": "9.3.1"}`})
+		h.svc.resultStore = store
+		h.svc.notifier = notifier
+		h.start()
+		require.ErrorIs(t, h.stop(), context.Canceled)
+		require.Equal(t, []string{"9.3.1"}, notifier.notified)
+		require.Equal(t, "9.3.1", store.status.NotifiedVersion)
+
+		restarted := &fakeNewVersionNotifier{}
+		h = newRunHarness(t, "9.3.0", scriptedResponse{body: `{"stable": "9.3.1"}`}, scriptedResponse{body: `{"stable": "9.3.2"}`})
+		h.svc.resultStore = store
+		h.svc.notifier = restarted
+		h.start()
+		require.Empty(t, restarted.notified)
+
+		h.tick()
+		require.ErrorIs(t, h.stop(), context.Canceled)
+		require.Equal(t, []string{"9.3.2"}, restarted.notified)
+	})
+
+	t.Run("recomputes the update after an upgrade", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.1", &fakeHTTPClient{})
+		svc.resultStore = &memoryResultStore{status: &UpdateStatus{
+			CurrentVersion:  "9.3.0",
+			LatestVersion:   "9.3.1",
+			UpdateAvailable: true,
+		}}
+
+		svc.loadStatus(context.Background())
+		require.Equal(t, "9.3.1", svc.LatestVersion())
+		require.False(t, svc.UpdateAvailable())
+	})
+}
+
+func TestKVResultStore(t *testing.T) {
+	store := ProvideKVResultStore(kvstore.NewFakeKVStore())
+
+	status, err := store.Load(context.Background())
+	require.NoError(t, err)
+	require.Nil(t, status)
+
+	saved := &UpdateStatus{
+		CurrentVersion:  "9.3.0",
+		LatestVersion:   "9.3.1",
+		UpdateAvailable: true,
+		CheckedAt:       time.Date(2023, time.March, 13, 10, 0, 0, 0, time.UTC),
+	}
+	require.NoError(t, store.Save(context.Background(), saved))
+
+	status, err = store.Load(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, saved, status)
+}
+
+type memoryResultStore struct {
+	mutex  sync.Mutex
+	status *UpdateStatus
+	saves  int
+}
+
+func (s *memoryResultStore) Load(context.Context) (*UpdateStatus, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.status, nil
+}
+
+func (s *memoryResultStore) Save(_ context.Context, status *UpdateStatus) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.status = status
+	s.saves++
+	return nil
+}
+
+func (s *memoryResultStore) saveCount() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.saves
+}
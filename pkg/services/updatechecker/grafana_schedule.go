@@ -0,0 +1,170 @@
+package updatechecker
+
+import (
+	"time"
+
+	"github.com/benbjohnson/clock"
+)
+
+const (
+	defaultCheckInterval = 10 * time.Minute
+	// minCheckInterval keeps a misconfigured interval from hammering the
+	// update servers.
+	minCheckInterval = time.Minute
+)
+
+// SetInterval changes the interval between checks. A running Run loop adopts
+// it right away, with the next check due one new interval from now. Intervals
+// below the minimum are raised to it.
+func (s *GrafanaService) SetInterval(d time.Duration) {
+	if d < minCheckInterval {
+		s.log.Warn("Update check interval is below the minimum, using the minimum instead", "interval", d, "minimum", minCheckInterval)
+		d = minCheckInterval
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if d == s.interval {
+		return
+	}
+	s.interval = d
+	if s.ticker != nil {
+		s.ticker.Reset(d)
+		s.tickerStartedAt = s.clock.Now()
+	}
+}
+
+// Interval returns the current interval between checks.
+func (s *GrafanaService) Interval() time.Duration {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.interval
+}
+
+func (s *GrafanaService) startTicker() *clock.Ticker {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.ticker = s.clock.Ticker(s.interval)
+	s.tickerStartedAt = s.clock.Now()
+	return s.ticker
+}
+
+// alignmentTimer returns a channel receiving the first multiple of the
+// interval on the wall clock after the ticker started, at which the ticker is
+// to be realigned, or nil if checks aren't aligned or already are.
+func (s *GrafanaService) alignmentTimer() <-chan time.Time {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if !s.alignChecks {
+		return nil
+	}
+	boundary := s.tickerStartedAt.Truncate(s.interval)
+	if boundary.Equal(s.tickerStartedAt) {
+		return nil
+	}
+	return s.clock.Timer(boundary.Add(s.interval).Sub(s.tickerStartedAt)).C
+}
+
+// alignTicker restarts the ticker at the interval boundary reached at tick, so
+// that every following tick lands on one.
+func (s *GrafanaService) alignTicker(tick time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.ticker.Reset(s.interval)
+	s.tickerStartedAt = tick
+}
+
+func (s *GrafanaService) stopTicker() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.ticker.Stop()
+	s.ticker = nil
+}
+
+// NextCheckAt returns when the Run loop will next check for updates, taking
+// backoff after failed checks and any snooze into account. It returns the zero
+// time until the first check has run.
+func (s *GrafanaService) NextCheckAt() time.Time {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.nextCheckAt()
+}
+
+// nextCheckAt is NextCheckAt for callers already holding the mutex.
+func (s *GrafanaService) nextCheckAt() time.Time {
+	if s.lastCheckAt.IsZero() {
+		return time.Time{}
+	}
+
+	next := s.nextTickAtOrAfter(s.lastCheckAt.Add(s.checkDelay()))
+	if next.Before(s.snoozedUntil) {
+		next = s.nextTickAtOrAfter(s.snoozedUntil)
+	}
+	return next
+}
+
+// recordCheck tracks the outcome of a check started at the given tick, which
+// the backoff and next check time are computed from.
+func (s *GrafanaService) recordCheck(tick time.Time, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.lastCheckAt = tick
+	if err != nil {
+		s.consecutiveFailures++
+	} else {
+		s.consecutiveFailures = 0
+	}
+}
+
+// isBackingOff reports whether a check at the given tick should be skipped
+// because of the backoff after consecutive failures.
+func (s *GrafanaService) isBackingOff(tick time.Time) bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if s.lastCheckAt.IsZero() || s.consecutiveFailures == 0 {
+		return false
+	}
+	return tick.Before(s.lastCheckAt.Add(s.checkDelay()))
+}
+
+// checkDelay returns the minimum time between the last check and the next
+// one. It doubles with every consecutive failure after the first, up to the
+// configured maximum backoff. Must be called with the mutex held.
+func (s *GrafanaService) checkDelay() time.Duration {
+	delay := s.interval
+	if s.maxBackoff <= 0 {
+		return delay
+	}
+
+	for i := 1; i < s.consecutiveFailures && delay < s.maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > s.maxBackoff {
+		delay = s.maxBackoff
+	}
+	return delay
+}
+
+// nextTickAtOrAfter returns the first tick of the Run loop's ticker at or after
+// t. Ticks are interval apart starting from when the ticker was last (re)set.
+// Must be called with the mutex held.
+func (s *GrafanaService) nextTickAtOrAfter(t time.Time) time.Time {
+	origin := s.tickerStartedAt
+	if origin.IsZero() {
+		origin = s.lastCheckAt
+	}
+
+	ticks := (t.Sub(origin) + s.interval - 1) / s.interval
+	if ticks < 1 {
+		ticks = 1
+	}
+	return origin.Add(ticks * s.interval)
+}
@@ -0,0 +1,235 @@
+package updatechecker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrafanaUpdateChecker_NextCheckAt(t *testing.T) {
+	t.Run("zero before the first check", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &scriptedHTTPClient{})
+		require.True(t, svc.NextCheckAt().IsZero())
+	})
+
+	t.Run("regular interval after a successful check", func(t *testing.T) {
+		h := newRunHarness(t, "9.3.0", scriptedResponse{body: `{"stable": "9.3.0"}`})
+		start := h.clock.Now()
+		h.start()
+
+		require.Equal(t, start.Add(10*time.Minute), h.svc.NextCheckAt())
+		require.ErrorIs(t, h.stop(), context.Canceled)
+	})
+
+	t.Run("backs off after consecutive failures", func(t *testing.T) {
+		h := newRunHarness(t, "9.3.0",
+			scriptedResponse{body: `{"stable": "9.3.0"}`},
+			scriptedResponse{err: errors.New("connection refused")},
+			scriptedResponse{err: errors.New("connection refused")},
+			scriptedResponse{err: errors.New("connection refused")},
+			scriptedResponse{body: `{"stable": "9.3.1"}`},
+		)
+		h.svc.maxBackoff = 30 * time.Minute
+		start := h.clock.Now()
+		h.start()
+		require.Equal(t, start.Add(10*time.Minute), h.svc.NextCheckAt())
+
+		// the first failure is retried on the regular interval
+		h.tick()
+		require.Equal(t, 2, h.client.requestCount())
+		require.Equal(t, start.Add(20*time.Minute), h.svc.NextCheckAt())
+
+		h.tick()
+		require.Equal(t, 3, h.client.requestCount())
+		require.Equal(t, start.Add(40*time.Minute), h.svc.NextCheckAt())
+
+		h.tick()
+		require.Equal(t, 3, h.client.requestCount())
+		require.Equal(t, start.Add(40*time.Minute), h.svc.NextCheckAt())
+
+		// capped by the maximum backoff
+		h.tick()
+		require.Equal(t, 4, h.client.requestCount())
+		require.Equal(t, start.Add(70*time.Minute), h.svc.NextCheckAt())
+
+		h.tick()
+		h.tick()
+		require.Equal(t, 4, h.client.requestCount())
+
+		// a successful check resets the backoff
+		h.tick()
+		require.Equal(t, 5, h.client.requestCount())
+		require.Equal(t, start.Add(80*time.Minute), h.svc.NextCheckAt())
+		h.requireState("9.3.1", true)
+
+		require.ErrorIs(t, h.stop(), context.Canceled)
+	})
+
+	t.Run("no backoff when disabled", func(t *testing.T) {
+		h := newRunHarness(t, "9.3.0",
+			scriptedResponse{err: errors.New("connection refused")},
+			scriptedResponse{err: errors.New("connection refused")},
+			scriptedResponse{err: errors.New("connection refused")},
+		)
+		start := h.clock.Now()
+		h.start()
+		h.tick()
+		h.tick()
+
+		require.Equal(t, 3, h.client.requestCount())
+		require.Equal(t, start.Add(30*time.Minute), h.svc.NextCheckAt())
+		require.ErrorIs(t, h.stop(), context.Canceled)
+	})
+
+	t.Run("accounts for a snooze", func(t *testing.T) {
+		h := newRunHarness(t, "9.3.0",
+			scriptedResponse{body: `{"stable": "9.3.0"}`},
+			scriptedResponse{body: `{"stable": "9.3.0"}`},
+		)
+		start := h.clock.Now()
+		h.start()
+
+		h.svc.Snooze(start.Add(25 * time.Minute))
+		require.Equal(t, start.Add(30*time.Minute), h.svc.NextCheckAt())
+
+		h.tick()
+		h.tick()
+		require.Equal(t, start.Add(30*time.Minute), h.svc.NextCheckAt())
+
+		h.tick()
+		require.Equal(t, 2, h.client.requestCount())
+		require.Equal(t, start.Add(40*time.Minute), h.svc.NextCheckAt())
+		require.ErrorIs(t, h.stop(), context.Canceled)
+	})
+}
+
+func TestGrafanaUpdateChecker_SetInterval(t *testing.T) {
+	t.Run("running loop adopts the new interval", func(t *testing.T) {
+		h := newRunHarness(t, "9.3.0",
+			scriptedResponse{body: `{"stable": "9.3.0"}`},
+			scriptedResponse{body: `{"stable": "9.3.0"}`},
+			scriptedResponse{body: `{"stable": "9.3.0"}`},
+			scriptedResponse{body: `{"stable": "9.3.1"}`},
+		)
+		start := h.clock.Now()
+		h.start()
+
+		h.clock.Add(5 * time.Minute)
+		h.svc.SetInterval(30 * time.Minute)
+		require.Equal(t, 30*time.Minute, h.svc.Interval())
+		require.Equal(t, start.Add(35*time.Minute), h.svc.NextCheckAt())
+
+		// the old cadence no longer applies
+		h.clock.Add(25 * time.Minute)
+		require.Equal(t, 1, h.client.requestCount())
+
+		h.clock.Add(5 * time.Minute)
+		h.waitForCheck()
+		require.Equal(t, 2, h.client.requestCount())
+		require.Equal(t, start.Add(65*time.Minute), h.svc.NextCheckAt())
+
+		h.clock.Add(30 * time.Minute)
+		h.waitForCheck()
+		require.Equal(t, 3, h.client.requestCount())
+
+		h.svc.SetInterval(10 * time.Minute)
+		h.tick()
+		require.Equal(t, 4, h.client.requestCount())
+		h.requireState("9.3.1", true)
+
+		require.ErrorIs(t, h.stop(), context.Canceled)
+	})
+
+	t.Run("interval is raised to the minimum", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &scriptedHTTPClient{})
+		svc.SetInterval(time.Second)
+		require.Equal(t, minCheckInterval, svc.Interval())
+	})
+
+	t.Run("takes effect when Run starts", func(t *testing.T) {
+		h := newRunHarness(t, "9.3.0",
+			scriptedResponse{body: `{"stable": "9.3.0"}`},
+			scriptedResponse{body: `{"stable": "9.3.0"}`},
+		)
+		h.svc.SetInterval(time.Hour)
+		start := h.clock.Now()
+		h.start()
+		require.Equal(t, start.Add(time.Hour), h.svc.NextCheckAt())
+
+		h.clock.Add(time.Hour)
+		h.waitForCheck()
+		require.Equal(t, 2, h.client.requestCount())
+
+		require.ErrorIs(t, h.stop(), context.Canceled)
+	})
+}
+
+func TestGrafanaUpdateChecker_alignChecks(t *testing.T) {
+	t.Run("first tick lands on the next interval boundary", func(t *testing.T) {
+		h := newRunHarness(t, "9.3.0",
+			scriptedResponse{body: `{"stable": "9.3.0"}`},
+			scriptedResponse{body: `{"stable": "9.3.0"}`},
+			scriptedResponse{body: `{"stable": "9.3.0"}`},
+		)
+		h.svc.alignChecks = true
+		h.svc.SetInterval(time.Hour)
+		h.clock.Set(time.Date(2023, time.March, 1, 10, 20, 0, 0, time.UTC))
+		h.start()
+		require.Equal(t, 1, h.client.requestCount())
+
+		h.clock.Add(40 * time.Minute)
+		h.waitForCheck()
+		require.Equal(t, 2, h.client.requestCount())
+		require.Equal(t, time.Date(2023, time.March, 1, 11, 0, 0, 0, time.UTC), h.clock.Now())
+		require.Equal(t, time.Date(2023, time.March, 1, 12, 0, 0, 0, time.UTC), h.svc.NextCheckAt())
+
+		h.clock.Add(time.Hour)
+		h.waitForCheck()
+		require.Equal(t, 3, h.client.requestCount())
+		require.Equal(t, time.Date(2023, time.March, 1, 13, 0, 0, 0, time.UTC), h.svc.NextCheckAt())
+
+		require.ErrorIs(t, h.stop(), context.Canceled)
+	})
+
+	t.Run("starting on a boundary keeps the regular ticks", func(t *testing.T) {
+		h := newRunHarness(t, "9.3.0",
+			scriptedResponse{body: `{"stable": "9.3.0"}`},
+			scriptedResponse{body: `{"stable": "9.3.0"}`},
+		)
+		h.svc.alignChecks = true
+		h.svc.SetInterval(time.Hour)
+		h.clock.Set(time.Date(2023, time.March, 1, 10, 0, 0, 0, time.UTC))
+		h.start()
+		require.Equal(t, time.Date(2023, time.March, 1, 11, 0, 0, 0, time.UTC), h.svc.NextCheckAt())
+
+		h.clock.Add(time.Hour)
+		h.waitForCheck()
+		require.Equal(t, 2, h.client.requestCount())
+
+		require.ErrorIs(t, h.stop(), context.Canceled)
+	})
+}
+
+func TestGrafanaUpdateChecker_maxChecks(t *testing.T) {
+	h := newRunHarness(t, "9.3.0",
+		scriptedResponse{body: `{"stable": "9.3.0"}`},
+		scriptedResponse{body: `{"stable": "9.3.0"}`},
+		scriptedResponse{body: `{"stable": "9.3.1"}`},
+	)
+	h.svc.maxChecks = 3
+	h.start()
+	h.tick()
+	h.tick()
+
+	select {
+	case err := <-h.runErr:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for Run to return")
+	}
+	require.Equal(t, 3, h.client.requestCount())
+	h.requireState("9.3.1", true)
+}
@@ -0,0 +1,75 @@
+package updatechecker
+
+import (
+	"context"
+	"time"
+)
+
+const snoozedUntilKey = "snoozed_until"
+
+// Snooze suppresses update checks until the given time, e.g. during planned
+// maintenance. Checks resume automatically once the deadline has passed, and a
+// zero time lifts the snooze right away. The deadline is persisted so that a
+// restart during the maintenance window doesn't end the snooze early.
+func (s *GrafanaService) Snooze(until time.Time) {
+	s.mutex.Lock()
+	s.snoozedUntil = until
+	s.mutex.Unlock()
+
+	if s.kvStore == nil {
+		return
+	}
+
+	ctx := context.Background()
+	var err error
+	if until.IsZero() {
+		err = s.kvStore.Del(ctx, snoozedUntilKey)
+	} else {
+		err = s.kvStore.Set(ctx, snoozedUntilKey, until.Format(time.RFC3339))
+	}
+	if err != nil {
+		s.log.Warn("Failed to persist update check snooze", "until", until, "error", err)
+	}
+}
+
+// SnoozedUntil returns the time until which update checks are snoozed, or the
+// zero time if they aren't.
+func (s *GrafanaService) SnoozedUntil() time.Time {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if !s.clock.Now().Before(s.snoozedUntil) {
+		return time.Time{}
+	}
+	return s.snoozedUntil
+}
+
+func (s *GrafanaService) isSnoozed() bool {
+	return !s.SnoozedUntil().IsZero()
+}
+
+// loadSnooze restores a snooze persisted before the last restart.
+func (s *GrafanaService) loadSnooze(ctx context.Context) {
+	if s.kvStore == nil {
+		return
+	}
+
+	val, ok, err := s.kvStore.Get(ctx, snoozedUntilKey)
+	if err != nil {
+		s.log.Warn("Failed to load update check snooze", "error", err)
+		return
+	}
+	if !ok {
+		return
+	}
+
+	until, err := time.Parse(time.RFC3339, val)
+	if err != nil {
+		s.log.Warn("Failed to parse persisted update check snooze", "value", val, "error", err)
+		return
+	}
+
+	s.mutex.Lock()
+	s.snoozedUntil = until
+	s.mutex.Unlock()
+}
@@ -0,0 +1,81 @@
+package updatechecker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+)
+
+func TestGrafanaUpdateChecker_Snooze(t *testing.T) {
+	t.Run("skips checks during the snooze window and resumes afterwards", func(t *testing.T) {
+		h := newRunHarness(t, "9.3.0",
+			scriptedResponse{body: `{"stable": "9.3.0"}`},
+			scriptedResponse{body: `{"stable": "9.4.0"}`},
+		)
+		h.start()
+		require.Equal(t, 1, h.client.requestCount())
+
+		until := h.clock.Now().Add(25 * time.Minute)
+		h.svc.Snooze(until)
+		require.Equal(t, until, h.svc.SnoozedUntil())
+
+		h.tick()
+		h.tick()
+		require.Equal(t, 1, h.client.requestCount())
+		h.requireState("9.3.0", false)
+
+		h.tick()
+		require.Equal(t, 2, h.client.requestCount())
+		require.True(t, h.svc.SnoozedUntil().IsZero())
+		h.requireState("9.4.0", true)
+
+		require.ErrorIs(t, h.stop(), context.Canceled)
+	})
+
+	t.Run("lifting the snooze resumes checks right away", func(t *testing.T) {
+		h := newRunHarness(t, "9.3.0",
+			scriptedResponse{body: `{"stable": "9.3.0"}`},
+			scriptedResponse{body: `{"stable": "9.4.0"}`},
+		)
+		h.start()
+
+		h.svc.Snooze(h.clock.Now().Add(time.Hour))
+		h.tick()
+		require.Equal(t, 1, h.client.requestCount())
+
+		h.svc.Snooze(time.Time{})
+		h.tick()
+		require.Equal(t, 2, h.client.requestCount())
+
+		require.ErrorIs(t, h.stop(), context.Canceled)
+	})
+
+	t.Run("snooze is persisted across restarts", func(t *testing.T) {
+		kv := kvstore.NewFakeKVStore()
+		until := time.Date(2023, time.March, 13, 12, 0, 0, 0, time.UTC)
+
+		before := newTestGrafanaService("9.3.0", &scriptedHTTPClient{})
+		before.kvStore = kvstore.WithNamespace(kv, 0, "updatechecker.grafana")
+		before.Snooze(until)
+
+		h := newRunHarness(t, "9.3.0", scriptedResponse{body: `{"stable": "9.4.0"}`})
+		h.svc.kvStore = kvstore.WithNamespace(kv, 0, "updatechecker.grafana")
+		h.clock.Set(until.Add(-15 * time.Minute))
+		h.start()
+		require.Equal(t, 0, h.client.requestCount())
+		require.True(t, until.Equal(h.svc.SnoozedUntil()))
+
+		h.tick()
+		require.Equal(t, 0, h.client.requestCount())
+
+		h.tick()
+		require.Equal(t, 1, h.client.requestCount())
+		h.requireState("9.4.0", true)
+
+		require.ErrorIs(t, h.stop(), context.Canceled)
+	})
+}
@@ -0,0 +1,40 @@
+package updatechecker
+
+import (
+	"time"
+)
+
+// isMirrorDataStale reports whether the update server advertises data older
+// than the configured threshold, warning about it since stale data can miss a
+// just released security fix. Payloads without generatedAt are never stale, nor
+// are any while the local clock isn't set. A local clock running ahead of the
+// update server by up to the clock skew tolerance doesn't make data stale.
+func (s *GrafanaService) isMirrorDataStale(url string, latest latestJSON) bool {
+	if latest.GeneratedAt == "" || s.staleThreshold <= 0 {
+		return false
+	}
+
+	generatedAt, err := time.Parse(time.RFC3339, latest.GeneratedAt)
+	if err != nil {
+		return false
+	}
+
+	now := s.clock.Now()
+	if s.clockUnset(now) {
+		return false
+	}
+	age := now.Sub(generatedAt)
+	if age <= s.staleThreshold+s.clockSkewTolerance {
+		return false
+	}
+	s.log.Warn("Update server data is stale", "url", url, "generatedAt", generatedAt, "age", age, "threshold", s.staleThreshold)
+	return true
+}
+
+// MirrorDataStale reports whether the data of the update server used by the
+// last successful check was older than the configured threshold.
+func (s *GrafanaService) MirrorDataStale() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.mirrorDataStale
+}
@@ -0,0 +1,67 @@
+package updatechecker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log/logtest"
+)
+
+func TestGrafanaUpdateChecker_MirrorDataStale(t *testing.T) {
+	tests := []struct {
+		name      string
+		payload   string
+		threshold time.Duration
+		tolerance time.Duration
+		stale     bool
+	}{
+		{name: "without generatedAt", payload: `{"stable": "9.3.0"}`, threshold: 24 * time.Hour},
+		{name: "fresh data", payload: `{"stable": "9.3.0", "generatedAt": "2023-03-13T08:00:00Z"}`, threshold: 24 * time.Hour},
+		{name: "stale data", payload: `{"stable": "9.3.0", "generatedAt": "2023-03-11T08:00:00Z"}`, threshold: 24 * time.Hour, stale: true},
+		{name: "within the clock skew tolerance", payload: `{"stable": "9.3.0", "generatedAt": "2023-03-12T09:55:00Z"}`, threshold: 24 * time.Hour, tolerance: 5 * time.Minute},
+		{name: "beyond the clock skew tolerance", payload: `{"stable": "9.3.0", "generatedAt": "2023-03-12T09:54:59Z"}`, threshold: 24 * time.Hour, tolerance: 5 * time.Minute, stale: true},
+		{name: "check disabled", payload: `{"stable": "9.3.0", "generatedAt": "2023-03-11T08:00:00Z"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &logtest.Fake{}
+			svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: tt.payload})
+			svc.log = logger
+			svc.staleThreshold = tt.threshold
+			svc.clockSkewTolerance = tt.tolerance
+			svc.clock.(*clock.Mock).Set(time.Date(2023, time.March, 13, 10, 0, 0, 0, time.UTC))
+
+			_, err := svc.checkForUpdates(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, tt.stale, svc.MirrorDataStale())
+			if tt.stale {
+				require.Equal(t, 1, logger.WarnLogs.Calls)
+				require.Equal(t, "Update server data is stale", logger.WarnLogs.Message)
+			} else {
+				require.Zero(t, logger.WarnLogs.Calls)
+			}
+		})
+	}
+
+	t.Run("clears once the data is fresh again", func(t *testing.T) {
+		client := &fakeHTTPClient{fakeResp: `{"stable": "9.3.0", "generatedAt": "2023-03-11T08:00:00Z"}`}
+		svc := newTestGrafanaService("9.3.0", client)
+		svc.log = &logtest.Fake{}
+		svc.staleThreshold = 24 * time.Hour
+		svc.clock.(*clock.Mock).Set(time.Date(2023, time.March, 13, 10, 0, 0, 0, time.UTC))
+
+		_, err := svc.checkForUpdates(context.Background())
+		require.NoError(t, err)
+		require.True(t, svc.MirrorDataStale())
+
+		client.fakeResp = `{"stable": "9.3.0", "generatedAt": "2023-03-13T09:00:00Z"}`
+		_, err = svc.checkForUpdates(context.Background())
+		require.NoError(t, err)
+		require.False(t, svc.MirrorDataStale())
+	})
+}
@@ -0,0 +1,140 @@
+package updatechecker
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/supportbundles"
+)
+
+// maxSupportBundlePayloadSize bounds how much of the last fetched payload is
+// kept around for support bundles.
+const maxSupportBundlePayloadSize = 64 * 1024
+
+// SupportBundleExport is the update checker state included in support bundles.
+type SupportBundleExport struct {
+	Payload          string               `json:"payload"`
+	PayloadTruncated bool                 `json:"payloadTruncated"`
+	PayloadURL       string               `json:"payloadUrl"`
+	FetchedAt        time.Time            `json:"fetchedAt"`
+	GeneratedAt      time.Time            `json:"generatedAt"`
+	Status           *SupportBundleStatus `json:"status,omitempty"`
+}
+
+// SupportBundleStatus is the update status computed from the last payload.
+type SupportBundleStatus struct {
+	CurrentVersion      string         `json:"currentVersion"`
+	LatestVersion       string         `json:"latestVersion"`
+	RecommendedVersion  string         `json:"recommendedVersion"`
+	UpdateAvailable     bool           `json:"updateAvailable"`
+	SecurityUpdate      bool           `json:"securityUpdate"`
+	CheckedSuccessfully bool           `json:"checkedSuccessfully"`
+	LastCheckAt         time.Time      `json:"lastCheckAt"`
+	NextCheckAt         time.Time      `json:"nextCheckAt"`
+	Snoozed             bool           `json:"snoozed"`
+	Mirrors             []MirrorStatus `json:"mirrors"`
+}
+
+// SupportBundleExport returns the last fetched payload along with the update
+// status computed from it. It is empty until a payload has been fetched.
+// Credentials in mirror URLs are redacted.
+func (s *GrafanaService) SupportBundleExport() SupportBundleExport {
+	return s.SupportBundleExportAt(s.clock.Now())
+}
+
+// SupportBundleExportAt is SupportBundleExport rendered at the given reference
+// time instead of the current time, so that snapshots, e.g. test fixtures,
+// can be reproduced.
+func (s *GrafanaService) SupportBundleExportAt(now time.Time) SupportBundleExport {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if s.lastPayloadAt.IsZero() {
+		return SupportBundleExport{}
+	}
+
+	mirrors := make([]MirrorStatus, len(s.mirrors))
+	for i, m := range s.mirrors {
+		m.URL = redactURL(m.URL)
+		mirrors[i] = m
+	}
+
+	return SupportBundleExport{
+		Payload:          string(s.lastPayload),
+		PayloadTruncated: s.lastPayloadTruncated,
+		PayloadURL:       redactURL(s.lastPayloadURL),
+		FetchedAt:        s.lastPayloadAt,
+		GeneratedAt:      now,
+		Status: &SupportBundleStatus{
+			CurrentVersion:      s.grafanaVersion,
+			LatestVersion:       s.latestVersion,
+			RecommendedVersion:  s.recommendedVersion,
+			UpdateAvailable:     s.hasUpdate,
+			SecurityUpdate:      s.securityUpdate,
+			CheckedSuccessfully: s.checkedSuccessfully,
+			LastCheckAt:         s.lastCheckAt,
+			NextCheckAt:         s.nextCheckAt(),
+			Snoozed:             now.Before(s.snoozedUntil),
+			Mirrors:             mirrors,
+		},
+	}
+}
+
+// recordPayload keeps the start of the last fetched payload for support bundles.
+func (s *GrafanaService) recordPayload(payload fetchedPayload, payloadURL string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.lastPayload = payload.head
+	s.lastPayloadTruncated = payload.truncated
+	s.lastPayloadURL = payloadURL
+	s.lastPayloadAt = s.clock.Now()
+}
+
+// payloadHead keeps the first max bytes written to it.
+type payloadHead struct {
+	max       int
+	buf       []byte
+	truncated bool
+}
+
+func (h *payloadHead) Write(p []byte) (int, error) {
+	n := len(p)
+	if room := h.max - len(h.buf); n > room {
+		p = p[:room]
+		h.truncated = true
+	}
+	h.buf = append(h.buf, p...)
+	return n, nil
+}
+
+func (s *GrafanaService) supportBundleCollector() supportbundles.Collector {
+	return supportbundles.Collector{
+		UID:               "update-checker",
+		DisplayName:       "Update checker",
+		Description:       "Last fetched latest.json and the update status computed from it",
+		IncludedByDefault: false,
+		Default:           true,
+		Fn: func(ctx context.Context) (*supportbundles.SupportItem, error) {
+			data, err := json.Marshal(s.SupportBundleExport())
+			if err != nil {
+				return nil, err
+			}
+			return &supportbundles.SupportItem{
+				Filename:  "update-checker.json",
+				FileBytes: data,
+			}, nil
+		},
+	}
+}
+
+// redactURL hides any credentials embedded in a mirror URL.
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,876 @@
+package updatechecker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/hashicorp/go-version"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/infra/usagestats"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/supportbundles/supportbundlestest"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestProvideGrafanaService(t *testing.T) {
+	t.Run("transport uses per-phase timeouts", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.UpdateCheckerTimeout = 20 * time.Second
+		cfg.UpdateCheckerDialTimeout = 3 * time.Second
+		cfg.UpdateCheckerTLSHandshakeTimeout = 4 * time.Second
+		cfg.UpdateCheckerResponseHeaderTimeout = 6 * time.Second
+
+		svc := provideTestGrafanaService(cfg)
+
+		client, ok := svc.httpClient.(*http.Client)
+		require.True(t, ok)
+		require.Equal(t, 20*time.Second, client.Timeout)
+
+		transport, ok := client.Transport.(*http.Transport)
+		require.True(t, ok)
+		require.NotNil(t, transport.DialContext)
+		require.Equal(t, 4*time.Second, transport.TLSHandshakeTimeout)
+		require.Equal(t, 6*time.Second, transport.ResponseHeaderTimeout)
+
+		require.Equal(t, 3*time.Second, newGrafanaDialer(cfg).Timeout)
+	})
+
+	t.Run("dialer uses the configured IP family", func(t *testing.T) {
+		for ipFamily, network := range map[string]string{"tcp": "tcp", "tcp4": "tcp4", "tcp6": "tcp6", "": "tcp", "ipv4": "tcp"} {
+			cfg := setting.NewCfg()
+			cfg.UpdateCheckerIPFamily = ipFamily
+			require.Equal(t, network, newGrafanaDialer(cfg).network, ipFamily)
+		}
+	})
+
+	t.Run("notifications are opt-in", func(t *testing.T) {
+		features := featuremgmt.WithFeatures(featuremgmt.FlagUpdateCheckerNotifications)
+		cfg := setting.NewCfg()
+		require.Nil(t, provideTestGrafanaServiceWithFeatures(cfg, features).notifier)
+
+		cfg.UpdateCheckerNotificationContactPoint = "ops"
+		cfg.UpdateCheckerNotificationOrgID = 1
+		require.Equal(t, &contactPointNotifier{orgID: 1, contactPoint: "ops"}, provideTestGrafanaServiceWithFeatures(cfg, features).notifier)
+	})
+
+	t.Run("notifications are gated by a feature toggle", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.UpdateCheckerNotificationContactPoint = "ops"
+
+		require.Nil(t, provideTestGrafanaService(cfg).notifier)
+		require.Nil(t, provideTestGrafanaServiceWithFeatures(cfg, featuremgmt.WithFeatures()).notifier)
+		require.NotNil(t, provideTestGrafanaServiceWithFeatures(cfg, featuremgmt.WithFeatures(featuremgmt.FlagUpdateCheckerNotifications)).notifier)
+	})
+
+	t.Run("development builds are not checked", func(t *testing.T) {
+		for _, v := range []string{"", "dev", "DEV", "unknown"} {
+			cfg := setting.NewCfg()
+			cfg.CheckForGrafanaUpdates = true
+			cfg.BuildVersion = v
+
+			require.True(t, provideTestGrafanaService(cfg).IsDisabled(), v)
+		}
+	})
+
+	t.Run("release builds are checked", func(t *testing.T) {
+		for _, v := range []string{"9.3.0", "v9.3.0", "9.4.0-beta1"} {
+			cfg := setting.NewCfg()
+			cfg.CheckForGrafanaUpdates = true
+			cfg.BuildVersion = v
+
+			require.False(t, provideTestGrafanaService(cfg).IsDisabled(), v)
+		}
+	})
+}
+
+func TestGrafanaUpdateChecker_Run(t *testing.T) {
+	t.Run("mixed failures, recoveries and version changes", func(t *testing.T) {
+		h := newRunHarness(t, "9.3.0",
+			scriptedResponse{body: `{"stable": "9.3.0", "testing": "9.4.0-beta1"}`},
+			scriptedResponse{err: errors.New("connection refused")},
+			scriptedResponse{body: `{"stable": "9.3.1", "testing": "9.4.0-beta1"}`},
+			scriptedResponse{body: `not json`},
+			scriptedResponse{err: errors.New("i/o timeout")},
+			scriptedResponse{body: `{"stable": "9.4.0", "testing": "9.5.0-beta1"}`},
+			scriptedResponse{body: `{"stable": "9.3.0", "testing": "9.5.0-beta1"}`},
+		)
+
+		h.start()
+		h.requireState("9.3.0", false)
+
+		h.tick()
+		h.requireState("9.3.0", false)
+
+		h.tick()
+		h.requireState("9.3.1", true)
+
+		h.tick()
+		h.requireState("9.3.1", true)
+
+		h.tick()
+		h.requireState("9.3.1", true)
+
+		h.tick()
+		h.requireState("9.4.0", true)
+
+		h.tick()
+		h.requireState("9.3.0", false)
+
+		require.ErrorIs(t, h.stop(), context.Canceled)
+		require.Equal(t, 7, h.client.requestCount())
+	})
+
+	t.Run("pre-release instance tracks testing", func(t *testing.T) {
+		h := newRunHarness(t, "9.4.0-beta1",
+			scriptedResponse{body: `{"stable": "9.3.2", "testing": "9.4.0-beta1"}`},
+			scriptedResponse{body: `{"stable": "9.3.2", "testing": "9.4.0-beta2"}`},
+			scriptedResponse{err: errors.New("connection reset by peer")},
+			scriptedResponse{body: `{"stable": "9.4.0", "testing": "9.4.0"}`},
+		)
+
+		h.start()
+		h.requireState("9.4.0-beta1", false)
+
+		h.tick()
+		h.requireState("9.4.0-beta2", true)
+
+		h.tick()
+		h.requireState("9.4.0-beta2", true)
+
+		h.tick()
+		h.requireState("9.4.0", true)
+
+		require.ErrorIs(t, h.stop(), context.Canceled)
+	})
+
+	t.Run("soak over hundreds of ticks", func(t *testing.T) {
+		const ticks = 500
+		const current = "9.3.0"
+		const maxBackoff = 4 * defaultCheckInterval
+
+		// The mirror only ever moves forward, so the latest version reported by
+		// the checker must never go backwards regardless of failures in between.
+		// It starts out failing long enough for the backoff to reach its maximum.
+		rnd := rand.New(rand.NewSource(42))
+		minor, patch := 3, 0
+		script := make([]scriptedResponse, 0, ticks+1)
+		failing := make([]bool, 0, ticks+1)
+		for i := 0; i <= ticks; i++ {
+			switch n := rnd.Intn(10); {
+			case i < 4 || n < 2:
+				script = append(script, scriptedResponse{err: errors.New("connection refused")})
+				failing = append(failing, true)
+			case n < 3:
+				script = append(script, scriptedResponse{body: `{"stable": `})
+				failing = append(failing, true)
+			default:
+				if rnd.Intn(4) == 0 {
+					patch++
+				}
+				if rnd.Intn(20) == 0 {
+					minor, patch = minor+1, 0
+				}
+				script = append(script, scriptedResponse{
+					body: fmt.Sprintf(`{"stable": "9.%d.%d", "testing": "9.%d.0-beta1"}`, minor, patch, minor+1),
+				})
+				failing = append(failing, false)
+			}
+		}
+
+		h := newRunHarness(t, current, script...)
+		h.svc.maxBackoff = maxBackoff
+		currVersion := version.Must(version.NewVersion(current))
+
+		// expectedDelay mirrors checkDelay: the interval doubles with every
+		// consecutive failure after the first, up to the maximum backoff.
+		expectedDelay := func(failures int) time.Duration {
+			delay := defaultCheckInterval
+			for i := 1; i < failures && delay < maxBackoff; i++ {
+				delay *= 2
+			}
+			if delay > maxBackoff {
+				delay = maxBackoff
+			}
+			return delay
+		}
+
+		var prev *version.Version
+		var requests, failures, lastCheckTick int
+		var backedOff, reset bool
+		for i := 0; i <= ticks; i++ {
+			if i == 0 {
+				h.start()
+			} else {
+				h.tick()
+			}
+
+			if n := h.client.requestCount(); n > requests {
+				require.Equal(t, requests+1, n, "tick %d", i)
+				if requests > 0 {
+					delay := time.Duration(i-lastCheckTick) * defaultCheckInterval
+					require.Equal(t, expectedDelay(failures), delay, "delay before the check at tick %d", i)
+					if delay == maxBackoff {
+						backedOff = true
+					} else if backedOff && delay == defaultCheckInterval {
+						reset = true
+					}
+				}
+				if failing[requests] {
+					failures++
+				} else {
+					failures = 0
+				}
+				requests, lastCheckTick = n, i
+			}
+
+			latest := h.svc.LatestVersion()
+			if latest == "" {
+				// no successful check yet
+				require.False(t, h.svc.UpdateAvailable())
+				continue
+			}
+
+			latestVersion, err := version.NewVersion(latest)
+			require.NoError(t, err)
+			if prev != nil {
+				require.False(t, latestVersion.LessThan(prev), "latest version went backwards at tick %d", i)
+			}
+			prev = latestVersion
+
+			require.Equal(t, currVersion.LessThan(latestVersion), h.svc.UpdateAvailable(), "tick %d", i)
+		}
+
+		require.ErrorIs(t, h.stop(), context.Canceled)
+		require.True(t, backedOff, "the backoff never reached its maximum")
+		require.True(t, reset, "the backoff never reset after reaching its maximum")
+		require.Less(t, requests, ticks+1)
+	})
+}
+
+func TestGrafanaUpdateChecker_checkForUpdates(t *testing.T) {
+	t.Run("top-level payload", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"stable": "9.3.1", "testing": "9.4.0-beta1"}`})
+
+		_, err := svc.checkForUpdates(context.Background())
+		require.NoError(t, err)
+		require.True(t, svc.UpdateAvailable())
+		require.Equal(t, "9.3.1", svc.LatestVersion())
+	})
+
+	t.Run("nested payload with key configured", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{
+			"products": {
+				"loki": {"stable": "2.7.4"},
+				"grafana": {"stable": "9.3.1", "testing": "9.4.0-beta1"}
+			}
+		}`})
+		svc.payloadKey = "products.grafana"
+
+		_, err := svc.checkForUpdates(context.Background())
+		require.NoError(t, err)
+		require.True(t, svc.UpdateAvailable())
+		require.Equal(t, "9.3.1", svc.LatestVersion())
+	})
+
+	t.Run("nested payload without key configured", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"grafana": {"stable": "9.3.1"}}`})
+
+		_, err := svc.checkForUpdates(context.Background())
+		require.Error(t, err)
+		require.False(t, svc.UpdateAvailable())
+		require.Empty(t, svc.LatestVersion())
+	})
+
+	t.Run("configured key missing from payload", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"stable": "9.3.1"}`})
+		svc.payloadKey = "grafana"
+		svc.latestVersion = "9.3.0"
+
+		_, err := svc.checkForUpdates(context.Background())
+		require.Error(t, err)
+		require.False(t, svc.UpdateAvailable())
+		require.Equal(t, "9.3.0", svc.LatestVersion())
+	})
+}
+
+func TestGrafanaUpdateChecker_platformSpecificVersions(t *testing.T) {
+	t.Run("uses the entry for the running platform", func(t *testing.T) {
+		payload := fmt.Sprintf(`{"stable": "9.3.1", "platforms": {"%s/%s": {"stable": "9.2.8"}}}`, runtime.GOOS, runtime.GOARCH)
+		svc := newTestGrafanaService("9.2.8", &fakeHTTPClient{fakeResp: payload})
+
+		_, err := svc.checkForUpdates(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "9.2.8", svc.LatestVersion())
+		require.False(t, svc.UpdateAvailable())
+	})
+
+	t.Run("falls back to the generic version without an entry for the running platform", func(t *testing.T) {
+		payload := `{"stable": "9.3.1", "platforms": {"plan9/mips": {"stable": "9.2.8"}}}`
+		svc := newTestGrafanaService("9.2.8", &fakeHTTPClient{fakeResp: payload})
+
+		_, err := svc.checkForUpdates(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "9.3.1", svc.LatestVersion())
+		require.True(t, svc.UpdateAvailable())
+	})
+}
+
+func TestGrafanaUpdateChecker_noLockDuringRequests(t *testing.T) {
+	client := &blockingHTTPClient{
+		body:    `{"stable": "9.3.1"}`,
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	svc := newTestGrafanaService("9.3.0", client)
+
+	checkErr := make(chan error, 1)
+	go func() {
+		_, err := svc.checkForUpdates(context.Background())
+		checkErr <- err
+	}()
+	<-client.started
+
+	// every getter must return while the request is in flight, which would
+	// block if the mutex were held across the network call
+	readersDone := make(chan struct{})
+	go func() {
+		defer close(readersDone)
+		assert.False(t, svc.UpdateAvailable())
+		assert.Empty(t, svc.LatestVersion())
+		assert.False(t, svc.HasCheckedSuccessfully())
+		assert.Equal(t, UpdateSeverityNone, svc.UpdateSeverity())
+		assert.Len(t, svc.Mirrors(), 1)
+		assert.True(t, svc.NextCheckAt().IsZero())
+		assert.Equal(t, SupportBundleExport{}, svc.SupportBundleExport())
+		svc.Snooze(time.Time{})
+	}()
+
+	select {
+	case <-readersDone:
+	case <-time.After(time.Second):
+		t.Fatal("state getters blocked while an update check was in flight")
+	}
+
+	close(client.release)
+	require.NoError(t, <-checkErr)
+	require.True(t, svc.UpdateAvailable())
+}
+
+func TestGrafanaUpdateChecker_HasCheckedSuccessfully(t *testing.T) {
+	client := &fakeHTTPClient{fakeResp: `{"stable": "9.3.0"}`}
+	svc := newTestGrafanaService("9.3.0", client)
+	require.False(t, svc.HasCheckedSuccessfully())
+
+	_, err := svc.checkForUpdates(context.Background())
+	require.NoError(t, err)
+	require.True(t, svc.HasCheckedSuccessfully())
+	require.False(t, svc.UpdateAvailable())
+
+	client.fakeResp = `not json`
+	_, err = svc.checkForUpdates(context.Background())
+	require.Error(t, err)
+	require.True(t, svc.HasCheckedSuccessfully())
+}
+
+func TestGrafanaUpdateChecker_notifications(t *testing.T) {
+	client := &fakeHTTPClient{fakeResp: `{"stable": "9.3.0"}`}
+	notifier := &fakeNewVersionNotifier{}
+	svc := newTestGrafanaService("9.3.0", client)
+	svc.notifier = notifier
+
+	_, err := svc.checkForUpdates(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, notifier.notified)
+
+	client.fakeResp = `{"stable": "9.3.1"}`
+	_, err = svc.checkForUpdates(context.Background())
+	require.NoError(t, err)
+	_, err = svc.checkForUpdates(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"9.3.1"}, notifier.notified)
+
+	client.fakeResp = `{"stable": "9.4.0"}`
+	notifier.err = errors.New("contact point unavailable")
+	_, err = svc.checkForUpdates(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"9.3.1"}, notifier.notified)
+
+	notifier.err = nil
+	_, err = svc.checkForUpdates(context.Background())
+	require.NoError(t, err)
+	_, err = svc.checkForUpdates(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"9.3.1", "9.4.0"}, notifier.notified)
+}
+
+func TestGrafanaUpdateChecker_Mirrors(t *testing.T) {
+	t.Run("defaults to the GitHub mirror", func(t *testing.T) {
+		svc := provideTestGrafanaService(setting.NewCfg())
+		require.Equal(t, []MirrorStatus{{URL: defaultLatestJSONURL}}, svc.Mirrors())
+	})
+
+	t.Run("falls back to the next mirror and tracks health", func(t *testing.T) {
+		client := &routingHTTPClient{routes: map[string]routedResponse{
+			"https://mirror1.example.com/latest.json": {statusCode: http.StatusServiceUnavailable},
+			"https://mirror2.example.com/latest.json": {statusCode: http.StatusOK, body: `{"stable": "9.3.1"}`},
+		}}
+		svc := newTestGrafanaService("9.3.0", client)
+		svc.mirrors = newMirrorStatuses([]string{
+			"https://mirror1.example.com/latest.json",
+			"https://mirror2.example.com/latest.json",
+		})
+		now := svc.clock.Now()
+
+		_, err := svc.checkForUpdates(context.Background())
+		require.NoError(t, err)
+		require.True(t, svc.UpdateAvailable())
+		require.Equal(t, "9.3.1", svc.LatestVersion())
+		require.Equal(t, []MirrorStatus{
+			{
+				URL:            "https://mirror1.example.com/latest.json",
+				LastError:      "unexpected status code 503",
+				LastStatusCode: http.StatusServiceUnavailable,
+			},
+			{
+				URL:            "https://mirror2.example.com/latest.json",
+				LastSuccess:    now,
+				LastStatusCode: http.StatusOK,
+			},
+		}, svc.Mirrors())
+
+		client.routes["https://mirror2.example.com/latest.json"] = routedResponse{err: errors.New("connection refused")}
+		_, err = svc.checkForUpdates(context.Background())
+		require.Error(t, err)
+		require.Equal(t, "9.3.1", svc.LatestVersion())
+
+		mirrors := svc.Mirrors()
+		require.Equal(t, "unexpected status code 503", mirrors[0].LastError)
+		require.Equal(t, "connection refused", mirrors[1].LastError)
+		require.Equal(t, 0, mirrors[1].LastStatusCode)
+		require.Equal(t, now, mirrors[1].LastSuccess)
+	})
+}
+
+func TestGrafanaUpdateChecker_RecommendedVersion(t *testing.T) {
+	t.Run("recommended version older than latest stable", func(t *testing.T) {
+		svc := newTestGrafanaService("9.1.0", &fakeHTTPClient{fakeResp: `{"stable": "9.4.0", "recommended": "9.2.5"}`})
+		_, err := svc.checkForUpdates(context.Background())
+		require.NoError(t, err)
+
+		require.True(t, svc.UpdateAvailable())
+		require.Equal(t, "9.4.0", svc.LatestVersion())
+
+		recommended, ok := svc.RecommendedVersion()
+		require.True(t, ok)
+		require.Equal(t, "9.2.5", recommended)
+		require.True(t, svc.RecommendedUpdateAvailable())
+	})
+
+	t.Run("running the recommended version", func(t *testing.T) {
+		svc := newTestGrafanaService("9.2.5", &fakeHTTPClient{fakeResp: `{"stable": "9.4.0", "recommended": "9.2.5"}`})
+		_, err := svc.checkForUpdates(context.Background())
+		require.NoError(t, err)
+
+		require.True(t, svc.UpdateAvailable())
+		require.False(t, svc.RecommendedUpdateAvailable())
+	})
+
+	t.Run("no recommended version", func(t *testing.T) {
+		svc := newTestGrafanaService("9.1.0", &fakeHTTPClient{fakeResp: `{"stable": "9.4.0"}`})
+		_, err := svc.checkForUpdates(context.Background())
+		require.NoError(t, err)
+
+		recommended, ok := svc.RecommendedVersion()
+		require.False(t, ok)
+		require.Empty(t, recommended)
+		require.True(t, svc.RecommendedUpdateAvailable())
+	})
+}
+
+func TestGrafanaUpdateChecker_SupportedVersionLines(t *testing.T) {
+	t.Run("listed by the payload", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"stable": "10.0.1", "supported": ["10.x", "9.5.x", "9.4.x"]}`})
+		_, err := svc.checkForUpdates(context.Background())
+		require.NoError(t, err)
+
+		require.Equal(t, []string{"10.x", "9.5.x", "9.4.x"}, svc.SupportedVersionLines())
+	})
+
+	t.Run("empty when absent", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"stable": "10.0.1"}`})
+		_, err := svc.checkForUpdates(context.Background())
+		require.NoError(t, err)
+
+		lines := svc.SupportedVersionLines()
+		require.NotNil(t, lines)
+		require.Empty(t, lines)
+	})
+}
+
+func TestGrafanaUpdateChecker_tracePropagation(t *testing.T) {
+	traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	require.NoError(t, err)
+	spanID, err := trace.SpanIDFromHex("00f067aa0ba902b7")
+	require.NoError(t, err)
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	client := &fakeHTTPClient{fakeResp: `{"stable": "9.3.1"}`}
+	svc := newTestGrafanaService("9.3.0", client)
+	_, err = svc.checkForUpdates(ctx)
+	require.NoError(t, err)
+
+	require.True(t, svc.UpdateAvailable())
+	require.Equal(t, defaultLatestJSONURL, client.requestURL)
+	require.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", client.requestHeader.Get("traceparent"))
+}
+
+func TestGrafanaUpdateChecker_method(t *testing.T) {
+	t.Run("GET by default", func(t *testing.T) {
+		svc := provideTestGrafanaService(setting.NewCfg())
+		require.Equal(t, http.MethodGet, svc.method)
+
+		client := &fakeHTTPClient{fakeResp: `{"stable": "9.3.1"}`}
+		svc = newTestGrafanaService("9.3.0", client)
+		_, err := svc.checkForUpdates(context.Background())
+		require.NoError(t, err)
+
+		require.Equal(t, http.MethodGet, client.requestMethod)
+		require.Empty(t, client.requestBody)
+		require.True(t, svc.UpdateAvailable())
+	})
+
+	t.Run("POST sends version and edition", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.UpdateCheckerMethod = "post"
+		require.Equal(t, http.MethodPost, provideTestGrafanaService(cfg).method)
+
+		client := &fakeHTTPClient{fakeResp: `{"stable": "9.3.1"}`}
+		svc := newTestGrafanaService("9.3.0", client)
+		svc.method = http.MethodPost
+		svc.edition = "enterprise"
+		_, err := svc.checkForUpdates(context.Background())
+		require.NoError(t, err)
+
+		require.Equal(t, http.MethodPost, client.requestMethod)
+		require.Equal(t, "application/json", client.requestHeader.Get("Content-Type"))
+		require.JSONEq(t, `{"version": "9.3.0", "edition": "enterprise"}`, string(client.requestBody))
+		require.True(t, svc.UpdateAvailable())
+		require.Equal(t, "9.3.1", svc.LatestVersion())
+	})
+
+	t.Run("unknown method falls back to GET", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.UpdateCheckerMethod = "PUT"
+		require.Equal(t, http.MethodGet, provideTestGrafanaService(cfg).method)
+	})
+}
+
+func TestGrafanaUpdateChecker_UpdateSeverity(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  string
+		resp     string
+		expected string
+	}{
+		{
+			name:     "up to date",
+			current:  "9.3.1",
+			resp:     `{"stable": "9.3.1"}`,
+			expected: UpdateSeverityNone,
+		},
+		{
+			name:     "patch update",
+			current:  "9.3.1",
+			resp:     `{"stable": "9.3.6"}`,
+			expected: UpdateSeverityLow,
+		},
+		{
+			name:     "minor update",
+			current:  "9.3.1",
+			resp:     `{"stable": "9.4.0"}`,
+			expected: UpdateSeverityMedium,
+		},
+		{
+			name:     "major update",
+			current:  "9.3.1",
+			resp:     `{"stable": "10.0.0"}`,
+			expected: UpdateSeverityHigh,
+		},
+		{
+			name:     "security update",
+			current:  "9.3.1",
+			resp:     `{"stable": "9.3.2", "security": true}`,
+			expected: UpdateSeverityCritical,
+		},
+		{
+			name:     "security flag without update",
+			current:  "9.3.2",
+			resp:     `{"stable": "9.3.2", "security": true}`,
+			expected: UpdateSeverityNone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestGrafanaService(tt.current, &fakeHTTPClient{fakeResp: tt.resp})
+
+			_, err := svc.checkForUpdates(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, svc.UpdateSeverity())
+		})
+	}
+}
+
+type fakeNewVersionNotifier struct {
+	err      error
+	notified []string
+}
+
+func (n *fakeNewVersionNotifier) NotifyNewVersion(_ context.Context, _, latestVersion string) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.notified = append(n.notified, latestVersion)
+	return nil
+}
+
+// runHarness drives GrafanaService.Run with a mock clock and a scripted HTTP
+// client, advancing one tick at a time and waiting for each check to finish.
+type runHarness struct {
+	t      *testing.T
+	svc    *GrafanaService
+	clock  *clock.Mock
+	client *scriptedHTTPClient
+
+	checked chan struct{}
+	cancel  context.CancelFunc
+	runErr  chan error
+}
+
+func newRunHarness(t *testing.T, grafanaVersion string, script ...scriptedResponse) *runHarness {
+	t.Helper()
+
+	client := &scriptedHTTPClient{responses: script}
+	svc := newTestGrafanaService(grafanaVersion, client)
+	h := &runHarness{
+		t:       t,
+		svc:     svc,
+		clock:   svc.clock.(*clock.Mock),
+		client:  client,
+		checked: make(chan struct{}, 1),
+		runErr:  make(chan error, 1),
+	}
+	svc.checkDoneFunc = func() {
+		h.checked <- struct{}{}
+	}
+
+	return h
+}
+
+func (h *runHarness) start() {
+	h.t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	h.cancel = cancel
+	go func() {
+		h.runErr <- h.svc.Run(ctx)
+	}()
+	h.waitForCheck()
+}
+
+func (h *runHarness) tick() {
+	h.t.Helper()
+
+	h.clock.Add(10 * time.Minute)
+	h.waitForCheck()
+}
+
+func (h *runHarness) stop() error {
+	h.t.Helper()
+
+	h.cancel()
+	select {
+	case err := <-h.runErr:
+		return err
+	case <-time.After(5 * time.Second):
+		h.t.Fatal("timed out waiting for Run to return")
+		return nil
+	}
+}
+
+func (h *runHarness) waitForCheck() {
+	h.t.Helper()
+
+	select {
+	case <-h.checked:
+	case <-time.After(5 * time.Second):
+		h.t.Fatal("timed out waiting for update check")
+	}
+}
+
+func (h *runHarness) requireState(latestVersion string, hasUpdate bool) {
+	h.t.Helper()
+
+	require.Equal(h.t, latestVersion, h.svc.LatestVersion())
+	require.Equal(h.t, hasUpdate, h.svc.UpdateAvailable())
+}
+
+type scriptedResponse struct {
+	body string
+	err  error
+}
+
+// scriptedHTTPClient replies to each request with the next scripted response.
+type scriptedHTTPClient struct {
+	responses []scriptedResponse
+
+	mutex    sync.Mutex
+	requests int
+}
+
+func (c *scriptedHTTPClient) Get(url string) (*http.Response, error) {
+	c.mutex.Lock()
+	i := c.requests
+	c.requests++
+	c.mutex.Unlock()
+
+	if i >= len(c.responses) {
+		return nil, errors.New("script exhausted")
+	}
+
+	r := c.responses[i]
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(r.body)),
+	}, nil
+}
+
+func (c *scriptedHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	return c.Get(req.URL.String())
+}
+
+func (c *scriptedHTTPClient) requestCount() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.requests
+}
+
+// blockingHTTPClient blocks every request until release is closed, signalling
+// started once the first request is in flight.
+type blockingHTTPClient struct {
+	body    string
+	started chan struct{}
+	release chan struct{}
+
+	once     sync.Once
+	requests int32
+	// ctxErr is the error of the request context once the request is released.
+	ctxErr error
+}
+
+func (c *blockingHTTPClient) Get(url string) (*http.Response, error) {
+	atomic.AddInt32(&c.requests, 1)
+	c.once.Do(func() { close(c.started) })
+	<-c.release
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(c.body)),
+	}, nil
+}
+
+func (c *blockingHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	resp, err := c.Get(req.URL.String())
+	c.ctxErr = req.Context().Err()
+	return resp, err
+}
+
+type routedResponse struct {
+	statusCode int
+	header     http.Header
+	body       string
+	err        error
+}
+
+// routingHTTPClient replies to each request based on its URL.
+type routingHTTPClient struct {
+	routes map[string]routedResponse
+
+	requested []string
+}
+
+func (c *routingHTTPClient) Get(url string) (*http.Response, error) {
+	c.requested = append(c.requested, url)
+	r, exists := c.routes[url]
+	if !exists {
+		return nil, fmt.Errorf("no route for %s", url)
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	return &http.Response{
+		StatusCode: r.statusCode,
+		Header:     r.header,
+		Body:       io.NopCloser(strings.NewReader(r.body)),
+	}, nil
+}
+
+func (c *routingHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	return c.Get(req.URL.String())
+}
+
+func provideTestGrafanaService(cfg *setting.Cfg) *GrafanaService {
+	return provideTestGrafanaServiceWithFeatures(cfg, nil)
+}
+
+func provideTestGrafanaServiceWithFeatures(cfg *setting.Cfg, features featuremgmt.FeatureToggles) *GrafanaService {
+	return ProvideGrafanaService(cfg, kvstore.NewFakeKVStore(), tracing.InitializeTracerForTest(), prometheus.NewRegistry(), nil, supportbundlestest.NewFakeBundleService(), &usagestats.UsageStatsMock{}, features, nil)
+}
+
+func newTestGrafanaService(grafanaVersion string, client httpClient) *GrafanaService {
+	s := &GrafanaService{
+		enabled:              true,
+		grafanaVersion:       grafanaVersion,
+		edition:              "oss",
+		parsedGrafanaVersion: parseVersion(grafanaVersion),
+		method:               http.MethodGet,
+		maxPages:             5,
+		interval:             defaultCheckInterval,
+		recheck:              make(chan struct{}, 1),
+		mirrors:              newMirrorStatuses(nil),
+		httpClient:           client,
+		tracer:               tracing.InitializeTracerForTest(),
+		metrics:              newGrafanaMetrics(prometheus.NewRegistry(), grafanaVersion, "oss"),
+		clock:                clock.NewMock(),
+		log:                  log.NewNopLogger(),
+	}
+	s.webhookCtx, s.stopWebhooks = context.WithCancel(context.Background())
+	return s
+}
@@ -0,0 +1,61 @@
+package updatechecker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// TestConnection requests latest.json from each configured mirror and reports
+// the ones that can't be reached or don't serve a readable payload, e.g. for a
+// "test connection" button in the admin settings. Unlike a check it doesn't
+// touch the update status, the mirror health or the response caches, and it
+// works while checks are disabled.
+func (s *GrafanaService) TestConnection(ctx context.Context) error {
+	ctx, span := s.tracer.Start(ctx, "updatechecker TestConnection")
+	defer span.End()
+
+	s.mutex.RLock()
+	urls := make([]string, 0, len(s.mirrors))
+	for _, m := range s.mirrors {
+		urls = append(urls, m.URL)
+	}
+	s.mutex.RUnlock()
+
+	var errs error
+	for _, url := range urls {
+		if err := s.testMirror(ctx, url); err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("%s: %w", url, err))
+		}
+	}
+	return errs
+}
+
+func (s *GrafanaService) testMirror(ctx context.Context, url string) error {
+	req, err := s.newLatestRequest(ctx, url)
+	if err != nil {
+		return err
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			s.log.Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	payload, err := s.decodeResponse(resp, s.payloadKey)
+	if err != nil {
+		return err
+	}
+	if payload.err != nil {
+		return fmt.Errorf("failed to unmarshal latest.json: %w", payload.err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package updatechecker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrafanaUpdateChecker_TestConnection(t *testing.T) {
+	const (
+		validURL       = "https://valid.example.com/latest.json"
+		unreachableURL = "https://unreachable.example.com/latest.json"
+		notFoundURL    = "https://not-found.example.com/latest.json"
+		invalidURL     = "https://invalid.example.com/latest.json"
+	)
+	client := &routingHTTPClient{routes: map[string]routedResponse{
+		validURL:       {statusCode: http.StatusOK, body: `{"stable": "9.3.1"}`},
+		unreachableURL: {err: errors.New("dial tcp: connection refused")},
+		notFoundURL:    {statusCode: http.StatusNotFound},
+		invalidURL:     {statusCode: http.StatusOK, body: `<html>Not latest.json</html>`},
+	}}
+
+	t.Run("reachable mirror serving latest.json", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", client)
+		svc.mirrors = newMirrorStatuses([]string{validURL})
+		svc.enabled = false
+
+		require.NoError(t, svc.TestConnection(context.Background()))
+	})
+
+	t.Run("unreachable or invalid mirrors", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", client)
+		svc.mirrors = newMirrorStatuses([]string{validURL, unreachableURL, notFoundURL, invalidURL})
+
+		err := svc.TestConnection(context.Background())
+		require.Error(t, err)
+		require.NotContains(t, err.Error(), validURL)
+		require.Contains(t, err.Error(), unreachableURL+": dial tcp: connection refused")
+		require.Contains(t, err.Error(), notFoundURL+": unexpected status code 404")
+		require.Contains(t, err.Error(), invalidURL+": failed to unmarshal latest.json")
+	})
+
+	t.Run("leaves the update state untouched", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", client)
+		svc.mirrors = newMirrorStatuses([]string{validURL, unreachableURL})
+
+		require.Error(t, svc.TestConnection(context.Background()))
+		require.False(t, svc.HasCheckedSuccessfully())
+		require.False(t, svc.UpdateAvailable())
+		require.Empty(t, svc.LatestVersion())
+		require.Equal(t, newMirrorStatuses([]string{validURL, unreachableURL}), svc.Mirrors())
+		_, cached := svc.cachedResponse(validURL)
+		require.False(t, cached)
+	})
+}
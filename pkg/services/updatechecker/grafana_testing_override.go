@@ -0,0 +1,21 @@
+package updatechecker
+
+// SetLatestVersionForTesting makes the checker report v as the latest version,
+// e.g. to test the update banner end-to-end without a mirror. It only works
+// in development mode and is ignored otherwise. The next check replaces the
+// version with the one of the update server.
+func (s *GrafanaService) SetLatestVersionForTesting(v string) {
+	if !s.testingOverrides {
+		s.log.Warn("Ignoring latest version override outside of development mode", "version", v)
+		return
+	}
+
+	latestVersion, parsedLatestVersion, hasUpdate := s.compare(latestJSON{Stable: v, Testing: v}, s.releaseChannel())
+
+	s.mutex.Lock()
+	s.latestVersion, s.parsedLatestVersion, s.hasUpdate = latestVersion, parsedLatestVersion, hasUpdate
+	s.mutex.Unlock()
+
+	s.metrics.updateAvailable.Set(boolToFloat64(hasUpdate))
+	s.log.Info("Overriding latest version for testing", "version", v, "updateAvailable", hasUpdate)
+}
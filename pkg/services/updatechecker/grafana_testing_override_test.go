@@ -0,0 +1,52 @@
+package updatechecker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestGrafanaUpdateChecker_SetLatestVersionForTesting(t *testing.T) {
+	t.Run("overrides the latest version in development mode", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"stable": "9.3.0"}`})
+		svc.testingOverrides = true
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.False(t, svc.UpdateAvailable())
+
+		svc.SetLatestVersionForTesting("9.4.0")
+		require.True(t, svc.UpdateAvailable())
+		require.Equal(t, "9.4.0", svc.LatestVersion())
+		require.Equal(t, 1.0, testutil.ToFloat64(svc.metrics.updateAvailable))
+
+		svc.SetLatestVersionForTesting("9.3.0")
+		require.False(t, svc.UpdateAvailable())
+
+		// the next check replaces the override
+		svc.SetLatestVersionForTesting("9.4.0")
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.False(t, svc.UpdateAvailable())
+		require.Equal(t, "9.3.0", svc.LatestVersion())
+	})
+
+	t.Run("is ignored otherwise", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"stable": "9.3.0"}`})
+		require.NoError(t, svc.CheckNow(context.Background()))
+
+		svc.SetLatestVersionForTesting("9.4.0")
+		require.False(t, svc.UpdateAvailable())
+		require.Equal(t, "9.3.0", svc.LatestVersion())
+	})
+
+	t.Run("only enabled in development mode", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.Env = setting.Prod
+		require.False(t, provideTestGrafanaService(cfg).testingOverrides)
+
+		cfg.Env = setting.Dev
+		require.True(t, provideTestGrafanaService(cfg).testingOverrides)
+	})
+}
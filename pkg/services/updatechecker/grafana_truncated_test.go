@@ -0,0 +1,82 @@
+package updatechecker
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrafanaUpdateChecker_largeAndTruncatedPayloads(t *testing.T) {
+	const url = "https://grafana.com/api/grafana/versions/latest"
+	var releases []string
+	for minor := 0; minor < 100; minor++ {
+		for patch := 0; patch < 500; patch++ {
+			releases = append(releases, fmt.Sprintf(`"9.%d.%d"`, minor, patch))
+		}
+	}
+	large := `{"releases": [` + strings.Join(releases, ", ") + `], "stable": "9.99.499"}`
+
+	gzipped := func(body string) string {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		_, err := gz.Write([]byte(body))
+		require.NoError(t, err)
+		require.NoError(t, gz.Close())
+		return buf.String()
+	}
+
+	t.Run("large payload", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: large})
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.Equal(t, "9.99.499", svc.LatestVersion())
+		payload, ok := svc.LastPayload()
+		require.True(t, ok)
+		require.Len(t, payload.Releases, 50000)
+	})
+
+	tests := []struct {
+		name     string
+		response routedResponse
+	}{
+		{
+			name:     "truncated JSON",
+			response: routedResponse{statusCode: http.StatusOK, body: large[:len(large)/2]},
+		},
+		{
+			name:     "truncated JSON with trailing whitespace",
+			response: routedResponse{statusCode: http.StatusOK, body: `{"stable": "9.3.1"` + "\n"},
+		},
+		{
+			name: "truncated gzip stream",
+			response: routedResponse{
+				statusCode: http.StatusOK,
+				header:     http.Header{"Content-Encoding": {"gzip"}},
+				body:       gzipped(large)[:1024],
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestGrafanaService("9.3.0", &routingHTTPClient{routes: map[string]routedResponse{url: tt.response}})
+			svc.mirrors = newMirrorStatuses([]string{url})
+
+			err := svc.CheckNow(context.Background())
+			require.ErrorIs(t, err, errTruncatedPayload)
+			require.False(t, svc.HasCheckedSuccessfully())
+		})
+	}
+
+	t.Run("malformed JSON isn't reported as truncated", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"stable": "9.3.1",, }`})
+		err := svc.CheckNow(context.Background())
+		require.ErrorContains(t, err, "failed to unmarshal latest.json")
+		require.NotErrorIs(t, err, errTruncatedPayload)
+	})
+}
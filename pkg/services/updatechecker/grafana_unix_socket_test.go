@@ -0,0 +1,82 @@
+package updatechecker
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestGrafanaUpdateChecker_unixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+
+	requests := make(chan *http.Request, 1)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- r
+		_, _ = w.Write([]byte(`{"stable": "9.3.1"}`))
+	})}
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(func() { require.NoError(t, server.Close()) })
+
+	cfg := setting.NewCfg()
+	cfg.CheckForGrafanaUpdates = true
+	cfg.BuildVersion = "9.3.0"
+	cfg.UpdateCheckerURLs = []string{"http://update-agent/v1/latest.json"}
+	cfg.UpdateCheckerUnixSocket = "unix://" + socket
+	svc := New(cfg, tracing.InitializeTracerForTest())
+
+	require.NoError(t, svc.CheckNow(context.Background()))
+	require.True(t, svc.UpdateAvailable())
+	require.Equal(t, "9.3.1", svc.LatestVersion())
+
+	r := <-requests
+	require.Equal(t, "update-agent", r.Host)
+	require.Equal(t, "/v1/latest.json", r.URL.Path)
+}
+
+func TestGrafanaUpdateChecker_unixSocketWebhook(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		_, _ = w.Write([]byte(`{"stable": "9.3.1"}`))
+	})}
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(func() { require.NoError(t, server.Close()) })
+
+	hooks := make(chan *http.Request, 1)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hooks <- r
+	}))
+	t.Cleanup(hook.Close)
+
+	cfg := setting.NewCfg()
+	cfg.CheckForGrafanaUpdates = true
+	cfg.BuildVersion = "9.3.0"
+	cfg.UpdateCheckerURLs = []string{"http://update-agent/v1/latest.json"}
+	cfg.UpdateCheckerUnixSocket = "unix://" + socket
+	cfg.UpdateCheckerWebhookURL = hook.URL + "/upgrade"
+	svc := New(cfg, tracing.InitializeTracerForTest())
+
+	require.NoError(t, svc.CheckNow(context.Background()))
+	svc.webhookCalls.Wait()
+
+	require.Len(t, hooks, 1)
+	r := <-hooks
+	require.Equal(t, http.MethodPost, r.Method)
+	require.Equal(t, "/upgrade", r.URL.Path)
+}
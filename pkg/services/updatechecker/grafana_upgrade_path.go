@@ -0,0 +1,18 @@
+package updatechecker
+
+// UpgradePath returns the versions to upgrade through, in order, to get from
+// the running version to the latest one, ending with the latest version. Large
+// jumps can require intermediate upgrades, e.g. 8.x to 9.x to 10.x, which the
+// update server lists per release line. Without that data the path is just the
+// latest version. It's empty without an update.
+func (s *GrafanaService) UpgradePath() []string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if !s.hasUpdate {
+		return nil
+	}
+	path := make([]string, 0, len(s.upgradeHops)+1)
+	path = append(path, s.upgradeHops...)
+	return append(path, s.latestVersion)
+}
@@ -0,0 +1,61 @@
+package updatechecker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrafanaUpdateChecker_UpgradePath(t *testing.T) {
+	tests := []struct {
+		name     string
+		version  string
+		resp     string
+		expected []string
+	}{
+		{
+			name:     "multi-hop path",
+			version:  "8.5.0",
+			resp:     `{"stable": "10.2.0", "upgradePath": {"8.x": ["8.5.27", "9.5.15"]}}`,
+			expected: []string{"8.5.27", "9.5.15", "10.2.0"},
+		},
+		{
+			name:     "minor line takes precedence",
+			version:  "8.5.0",
+			resp:     `{"stable": "10.2.0", "upgradePath": {"8.x": ["9.0.0"], "8.5.x": ["9.5.15"]}}`,
+			expected: []string{"9.5.15", "10.2.0"},
+		},
+		{
+			name:     "hops outside of the range are skipped",
+			version:  "9.5.15",
+			resp:     `{"stable": "10.2.0", "upgradePath": {"9.x": ["9.5.15", "10.2.0", "10.3.0"]}}`,
+			expected: []string{"10.2.0"},
+		},
+		{
+			name:     "direct upgrade",
+			version:  "9.3.0",
+			resp:     `{"stable": "9.4.0"}`,
+			expected: []string{"9.4.0"},
+		},
+		{
+			name:     "no path for the running line",
+			version:  "9.3.0",
+			resp:     `{"stable": "10.2.0", "upgradePath": {"8.x": ["9.5.15"]}}`,
+			expected: []string{"10.2.0"},
+		},
+		{
+			name:    "no update",
+			version: "10.2.0",
+			resp:    `{"stable": "10.2.0", "upgradePath": {"8.x": ["9.5.15"]}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestGrafanaService(tt.version, &fakeHTTPClient{fakeResp: tt.resp})
+			require.NoError(t, svc.CheckNow(context.Background()))
+			require.Equal(t, tt.expected, svc.UpgradePath())
+		})
+	}
+}
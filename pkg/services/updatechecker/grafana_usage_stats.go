@@ -0,0 +1,55 @@
+package updatechecker
+
+import (
+	"context"
+)
+
+// Buckets of how far the running version is behind the latest one, as
+// reported in usage stats. Only the bucket is reported, never the versions.
+const (
+	behindUpToDate = "up_to_date"
+	behindPatch    = "patch"
+	behindMinor1   = "1_minor"
+	behindMinor2_3 = "2_3_minor"
+	behindMinor4   = "4_plus_minor"
+	behindMajor    = "major"
+)
+
+// usageStats reports how far the running version is behind, once it's known.
+func (s *GrafanaService) usageStats(context.Context) (map[string]interface{}, error) {
+	bucket := s.versionsBehindBucket()
+	if bucket == "" {
+		return map[string]interface{}{}, nil
+	}
+	return map[string]interface{}{
+		"stats.updatechecker.versions_behind." + bucket + ".count": 1,
+	}, nil
+}
+
+// versionsBehindBucket buckets the distance between the running and the
+// latest version, or returns an empty string if either is unknown.
+func (s *GrafanaService) versionsBehindBucket() string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if s.parsedGrafanaVersion == nil || s.parsedLatestVersion == nil {
+		return ""
+	}
+	if !s.parsedGrafanaVersion.LessThan(s.parsedLatestVersion) {
+		return behindUpToDate
+	}
+
+	curr, latest := s.parsedGrafanaVersion.Segments(), s.parsedLatestVersion.Segments()
+	switch minors := latest[1] - curr[1]; {
+	case latest[0] != curr[0]:
+		return behindMajor
+	case minors == 0:
+		return behindPatch
+	case minors == 1:
+		return behindMinor1
+	case minors <= 3:
+		return behindMinor2_3
+	default:
+		return behindMinor4
+	}
+}
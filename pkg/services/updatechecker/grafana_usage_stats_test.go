@@ -0,0 +1,55 @@
+package updatechecker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/infra/usagestats"
+	"github.com/grafana/grafana/pkg/services/supportbundles/supportbundlestest"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestGrafanaUpdateChecker_usageStats(t *testing.T) {
+	report := func(t *testing.T, reportingEnabled bool, latest string) map[string]interface{} {
+		cfg := setting.NewCfg()
+		cfg.BuildVersion = "9.3.0"
+		cfg.CheckForGrafanaUpdates = true
+		cfg.ReportingEnabled = reportingEnabled
+		usageStats := &usagestats.UsageStatsMock{T: t}
+		svc := ProvideGrafanaService(cfg, kvstore.NewFakeKVStore(), tracing.InitializeTracerForTest(), prometheus.NewRegistry(), nil, supportbundlestest.NewFakeBundleService(), usageStats, nil, nil)
+		svc.httpClient = &fakeHTTPClient{fakeResp: `{"stable": "` + latest + `"}`}
+		require.NoError(t, svc.CheckNow(context.Background()))
+
+		r, err := usageStats.GetUsageReport(context.Background())
+		require.NoError(t, err)
+		return r.Metrics
+	}
+
+	t.Run("reports the bucketed distance to the latest version", func(t *testing.T) {
+		for latest, bucket := range map[string]string{
+			"9.3.0":  behindUpToDate,
+			"9.3.2":  behindPatch,
+			"9.4.0":  behindMinor1,
+			"9.6.1":  behindMinor2_3,
+			"9.7.0":  behindMinor4,
+			"10.0.0": behindMajor,
+		} {
+			require.Equal(t, map[string]interface{}{"stats.updatechecker.versions_behind." + bucket + ".count": 1}, report(t, true, latest), latest)
+		}
+	})
+
+	t.Run("nothing is reported without usage stats", func(t *testing.T) {
+		require.Empty(t, report(t, false, "9.4.0"))
+	})
+
+	t.Run("nothing is reported before the first check", func(t *testing.T) {
+		metrics, err := newTestGrafanaService("9.3.0", &fakeHTTPClient{}).usageStats(context.Background())
+		require.NoError(t, err)
+		require.Empty(t, metrics)
+	})
+}
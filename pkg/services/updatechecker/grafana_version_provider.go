@@ -0,0 +1,46 @@
+package updatechecker
+
+import (
+	"github.com/hashicorp/go-version"
+)
+
+// SetVersionProvider makes every check read the running version from fn
+// instead of the version Grafana was built with, for deployments that update
+// Grafana in place without restarting the process. A nil fn keeps the version
+// read by the last check.
+func (s *GrafanaService) SetVersionProvider(fn func() string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.versionProvider = fn
+}
+
+// refreshVersion reads the running version from the version provider, if
+// there is one, so that the next comparison uses it.
+func (s *GrafanaService) refreshVersion() {
+	s.mutex.RLock()
+	provider := s.versionProvider
+	s.mutex.RUnlock()
+	if provider == nil {
+		return
+	}
+
+	current := provider()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if current == s.grafanaVersion {
+		return
+	}
+	s.log.Info("Running Grafana version changed", "from", s.grafanaVersion, "to", current)
+	s.grafanaVersion, s.parsedGrafanaVersion = current, parseVersion(current)
+}
+
+// runningVersion returns the running version for code that doesn't hold the
+// mutex.
+func (s *GrafanaService) runningVersion() (string, *version.Version) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.grafanaVersion, s.parsedGrafanaVersion
+}
@@ -0,0 +1,45 @@
+package updatechecker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrafanaUpdateChecker_versionProvider(t *testing.T) {
+	t.Run("checks compare against the version the provider returns", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"stable": "9.3.1"}`})
+		running := "9.3.0"
+		svc.SetVersionProvider(func() string { return running })
+
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.True(t, svc.UpdateAvailable())
+
+		running = "9.3.1"
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.False(t, svc.UpdateAvailable())
+		require.Equal(t, "9.3.1", svc.Status().CurrentVersion)
+	})
+
+	t.Run("picks the release channel of the provided version", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"stable": "9.3.0", "testing": "9.4.0-beta2"}`})
+		svc.SetVersionProvider(func() string { return "9.4.0-beta1" })
+
+		status, err := svc.CheckNowWithChannel(context.Background(), channelTesting)
+		require.NoError(t, err)
+		require.Equal(t, "9.4.0-beta1", status.CurrentVersion)
+		require.True(t, status.UpdateAvailable)
+
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.Equal(t, "9.4.0-beta2", svc.LatestVersion())
+	})
+
+	t.Run("uses the build version without a provider", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"stable": "9.3.1"}`})
+
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.True(t, svc.UpdateAvailable())
+		require.Equal(t, "9.3.0", svc.Status().CurrentVersion)
+	})
+}
@@ -0,0 +1,79 @@
+package updatechecker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrafanaUpdateChecker_parsedVersions(t *testing.T) {
+	t.Run("parsed versions follow the string fields", func(t *testing.T) {
+		client := &fakeHTTPClient{fakeResp: `{"stable": "9.3.1", "testing": "9.4.0-beta1"}`}
+		svc := newTestGrafanaService("9.3.0", client)
+		require.Equal(t, "9.3.0", svc.parsedGrafanaVersion.Original())
+		require.Nil(t, svc.parsedLatestVersion)
+
+		for _, payload := range []string{
+			`{"stable": "9.3.1", "testing": "9.4.0-beta1"}`,
+			`{"stable": "9.4.0", "testing": "9.5.0-beta1"}`,
+			`{"stable": "v9.4.1"}`,
+		} {
+			client.fakeResp = payload
+			_, err := svc.checkForUpdates(context.Background())
+			require.NoError(t, err)
+
+			require.NotNil(t, svc.parsedLatestVersion)
+			require.Equal(t, svc.latestVersion, svc.parsedLatestVersion.Original())
+		}
+	})
+
+	t.Run("invalid running version isn't parsed", func(t *testing.T) {
+		svc := newTestGrafanaService("main", &fakeHTTPClient{fakeResp: `{"stable": "9.3.1"}`})
+		require.Nil(t, svc.parsedGrafanaVersion)
+
+		_, err := svc.checkForUpdates(context.Background())
+		require.NoError(t, err)
+		require.True(t, svc.UpdateAvailable())
+		require.Equal(t, UpdateSeverityLow, svc.UpdateSeverity())
+	})
+
+	t.Run("invalid testing version isn't parsed", func(t *testing.T) {
+		svc := newTestGrafanaService("9.4.0-beta1", &fakeHTTPClient{fakeResp: `{"stable": "9.3.1"}`})
+
+		_, err := svc.checkForUpdates(context.Background())
+		require.NoError(t, err)
+		require.Empty(t, svc.latestVersion)
+		require.Nil(t, svc.parsedLatestVersion)
+	})
+}
+
+func BenchmarkGrafanaUpdateChecker_getters(b *testing.B) {
+	svc := newTestGrafanaService("9.1.4", &fakeHTTPClient{fakeResp: `{"stable": "9.4.7", "releaseDates": {"9.4.7": "2023-03-01"}}`})
+	if _, err := svc.checkForUpdates(context.Background()); err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("cached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			svc.UpdateSeverity()
+			svc.BehindSummary()
+		}
+	})
+
+	// reparsing the versions before every call, as the getters used to
+	b.Run("reparsing", func(b *testing.B) {
+		b.ReportAllocs()
+		reparse := func() {
+			svc.parsedGrafanaVersion = parseVersion(svc.grafanaVersion)
+			svc.parsedLatestVersion = parseVersion(svc.latestVersion)
+		}
+		for i := 0; i < b.N; i++ {
+			reparse()
+			svc.UpdateSeverity()
+			reparse()
+			svc.BehindSummary()
+		}
+	})
+}
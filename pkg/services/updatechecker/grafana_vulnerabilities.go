@@ -0,0 +1,45 @@
+package updatechecker
+
+import (
+	"github.com/hashicorp/go-version"
+)
+
+// vulnerability is a known vulnerability listed by the update server.
+type vulnerability struct {
+	CVE string `json:"cve"`
+	// Versions is the affected version range as a go-version constraint,
+	// e.g. ">= 9.0.0, < 9.3.8".
+	Versions string `json:"versions"`
+}
+
+// affectingVulnerabilities returns the IDs of the vulnerabilities affecting the
+// running version, in payload order.
+func (s *GrafanaService) affectingVulnerabilities(vulnerabilities []vulnerability) []string {
+	if s.parsedGrafanaVersion == nil {
+		return nil
+	}
+
+	var ids []string
+	seen := map[string]bool{}
+	for _, v := range vulnerabilities {
+		constraints, err := version.NewConstraint(v.Versions)
+		if err != nil || seen[v.CVE] || !constraints.Check(s.parsedGrafanaVersion) {
+			continue
+		}
+		seen[v.CVE] = true
+		ids = append(ids, v.CVE)
+	}
+	return ids
+}
+
+// KnownVulnerabilities returns the IDs, e.g. CVE-2023-1387, of the
+// vulnerabilities the update server lists as affecting the running version.
+// It's empty if the server doesn't list any.
+func (s *GrafanaService) KnownVulnerabilities() []string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	ids := make([]string, len(s.vulnerabilities))
+	copy(ids, s.vulnerabilities)
+	return ids
+}
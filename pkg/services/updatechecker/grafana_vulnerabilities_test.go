@@ -0,0 +1,42 @@
+package updatechecker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrafanaUpdateChecker_KnownVulnerabilities(t *testing.T) {
+	const payload = `{
+		"stable": "9.4.7",
+		"vulnerable": [
+			{"cve": "CVE-2023-1387", "versions": ">= 9.1.0, < 9.2.17"},
+			{"cve": "CVE-2023-1410", "versions": ">= 9.2.0, < 9.4.7"},
+			{"cve": "CVE-2023-0507", "versions": "< 9.1.0"},
+			{"cve": "CVE-2023-1410", "versions": ">= 8.0.0, < 8.5.22"}
+		]
+	}`
+
+	tests := []struct {
+		name            string
+		grafanaVersion  string
+		payload         string
+		vulnerabilities []string
+	}{
+		{name: "affected by several", grafanaVersion: "9.2.10", payload: payload, vulnerabilities: []string{"CVE-2023-1387", "CVE-2023-1410"}},
+		{name: "affected by one", grafanaVersion: "9.3.0", payload: payload, vulnerabilities: []string{"CVE-2023-1410"}},
+		{name: "not affected", grafanaVersion: "9.4.7", payload: payload, vulnerabilities: []string{}},
+		{name: "without vulnerable list", grafanaVersion: "9.2.10", payload: `{"stable": "9.4.7"}`, vulnerabilities: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestGrafanaService(tt.grafanaVersion, &fakeHTTPClient{fakeResp: tt.payload})
+			_, err := svc.checkForUpdates(context.Background())
+			require.NoError(t, err)
+
+			require.Equal(t, tt.vulnerabilities, svc.KnownVulnerabilities())
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package updatechecker
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/benbjohnson/clock"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+const (
+	webhookAttempts        = 3
+	webhookSignatureHeader = "X-Grafana-Signature"
+)
+
+// webhookPayload is posted to the webhook whenever a new version is detected.
+type webhookPayload struct {
+	Current    string    `json:"current"`
+	Latest     string    `json:"latest"`
+	DetectedAt time.Time `json:"detectedAt"`
+	Channel    string    `json:"channel"`
+}
+
+// webhookNotifier posts new-version payloads to a URL, signing them with an
+// HMAC-SHA256 of the body when a secret is configured.
+type webhookNotifier struct {
+	url        string
+	secret     string
+	retryDelay time.Duration
+	httpClient httpClient
+	clock      clock.Clock
+	log        log.Logger
+}
+
+// newWebhookHTTPClient builds the client webhooks are posted with. Webhooks
+// usually go elsewhere than update checks, so they don't share the socket,
+// IP family or redirect policy of the update check client.
+func newWebhookHTTPClient(timeouts RequestTimeouts) *http.Client {
+	return &http.Client{Timeout: timeouts.Total}
+}
+
+// send posts payload, retrying failed attempts a bounded number of times.
+func (n *webhookNotifier) send(ctx context.Context, payload webhookPayload) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		n.log.Warn("Failed to encode update webhook payload", "error", err)
+		return
+	}
+
+	for attempt := 1; attempt <= webhookAttempts; attempt++ {
+		err = n.post(ctx, body)
+		if err == nil {
+			return
+		}
+		if attempt == webhookAttempts {
+			break
+		}
+		select {
+		case <-n.clock.After(time.Duration(attempt) * n.retryDelay):
+		case <-ctx.Done():
+			n.log.Debug("Stopped retrying update webhook", "version", payload.Latest, "attempts", attempt, "error", err)
+			return
+		}
+	}
+	n.log.Warn("Failed to call update webhook", "version", payload.Latest, "attempts", webhookAttempts, "error", err)
+}
+
+func (n *webhookNotifier) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.secret != "" {
+		req.Header.Set(webhookSignatureHeader, "sha256="+signWebhookBody(n.secret, body))
+	}
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			n.log.Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func signWebhookBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// callWebhook posts payload in the background so that a slow or failing
+// webhook doesn't hold up the check loop. The call outlives the check that
+// triggered it, e.g. an API request, and is only cancelled when Run stops.
+func (s *GrafanaService) callWebhook(payload webhookPayload) {
+	s.webhookCalls.Add(1)
+	go func() {
+		defer s.webhookCalls.Done()
+		s.webhook.send(s.webhookCtx, payload)
+	}()
+}
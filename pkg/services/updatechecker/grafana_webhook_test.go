@@ -0,0 +1,239 @@
+package updatechecker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestGrafanaUpdateChecker_webhook(t *testing.T) {
+	t.Run("fires once per new version with a valid signature", func(t *testing.T) {
+		client := &fakeHTTPClient{fakeResp: `{"stable": "9.3.1"}`}
+		svc := newTestGrafanaService("9.3.0", client)
+		hook := &webhookHTTPClient{}
+		svc.webhook = newTestWebhookNotifier(hook, "s3cr3t")
+
+		require.NoError(t, svc.CheckNow(context.Background()))
+		require.NoError(t, svc.CheckNow(context.Background()))
+		svc.webhookCalls.Wait()
+		require.Len(t, hook.requests(), 1)
+
+		client.fakeResp = `{"stable": "9.4.0"}`
+		require.NoError(t, svc.CheckNow(context.Background()))
+		svc.webhookCalls.Wait()
+
+		requests := hook.requests()
+		require.Len(t, requests, 2)
+		for i, latest := range []string{"9.3.1", "9.4.0"} {
+			r := requests[i]
+			require.Equal(t, "https://hooks.example.com/upgrade", r.url)
+			require.Equal(t, "application/json", r.header.Get("Content-Type"))
+			require.Equal(t, "sha256="+signWebhookBody("s3cr3t", r.body), r.header.Get(webhookSignatureHeader))
+
+			var payload webhookPayload
+			require.NoError(t, json.Unmarshal(r.body, &payload))
+			require.True(t, svc.clock.Now().Equal(payload.DetectedAt))
+			payload.DetectedAt = time.Time{}
+			require.Equal(t, webhookPayload{Current: "9.3.0", Latest: latest, Channel: channelStable}, payload)
+		}
+	})
+
+	t.Run("doesn't fire without an update", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"stable": "9.3.0"}`})
+		hook := &webhookHTTPClient{}
+		svc.webhook = newTestWebhookNotifier(hook, "")
+
+		require.NoError(t, svc.CheckNow(context.Background()))
+		svc.webhookCalls.Wait()
+		require.Empty(t, hook.requests())
+	})
+
+	t.Run("payloads are unsigned without a secret", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"stable": "9.3.1"}`})
+		hook := &webhookHTTPClient{}
+		svc.webhook = newTestWebhookNotifier(hook, "")
+
+		require.NoError(t, svc.CheckNow(context.Background()))
+		svc.webhookCalls.Wait()
+		require.Len(t, hook.requests(), 1)
+		require.Empty(t, hook.requests()[0].header.Get(webhookSignatureHeader))
+	})
+
+	t.Run("failed calls are retried a bounded number of times", func(t *testing.T) {
+		hook := &webhookHTTPClient{statuses: []int{http.StatusBadGateway, http.StatusOK}}
+		newTestWebhookNotifier(hook, "").send(context.Background(), webhookPayload{Latest: "9.3.1"})
+		require.Len(t, hook.requests(), 2)
+
+		hook = &webhookHTTPClient{err: errors.New("connection refused")}
+		newTestWebhookNotifier(hook, "").send(context.Background(), webhookPayload{Latest: "9.3.1"})
+		require.Len(t, hook.requests(), webhookAttempts)
+	})
+
+	t.Run("doesn't block the check", func(t *testing.T) {
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"stable": "9.3.1"}`})
+		hook := &webhookHTTPClient{release: make(chan struct{})}
+		svc.webhook = newTestWebhookNotifier(hook, "")
+
+		require.NoError(t, svc.CheckNow(context.Background()))
+		close(hook.release)
+		svc.webhookCalls.Wait()
+		require.Len(t, hook.requests(), 1)
+	})
+
+	t.Run("stops retrying when cancelled", func(t *testing.T) {
+		hook := &webhookHTTPClient{err: errors.New("connection refused")}
+		notifier := newTestWebhookNotifier(hook, "")
+		notifier.retryDelay = time.Hour
+		ctx, cancel := context.WithCancel(context.Background())
+
+		done := make(chan struct{})
+		go func() {
+			notifier.send(ctx, webhookPayload{Latest: "9.3.1"})
+			close(done)
+		}()
+		require.Eventually(t, func() bool { return len(hook.requests()) == 1 }, time.Second, time.Millisecond)
+		cancel()
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the webhook to stop retrying")
+		}
+		require.Len(t, hook.requests(), 1)
+	})
+
+	t.Run("is cancelled and waited for when Run stops", func(t *testing.T) {
+		h := newRunHarness(t, "9.3.0", scriptedResponse{body: `{"stable": "9.3.1"}`})
+		hook := &webhookHTTPClient{release: make(chan struct{}), started: make(chan struct{}, 1)}
+		h.svc.webhook = newTestWebhookNotifier(hook, "")
+
+		h.start()
+		<-hook.started
+		require.ErrorIs(t, h.stop(), context.Canceled)
+
+		hook.mutex.Lock()
+		defer hook.mutex.Unlock()
+		require.Equal(t, 1, hook.cancelled)
+		require.Empty(t, hook.received)
+	})
+
+	t.Run("doesn't fire for the same version again after a restart", func(t *testing.T) {
+		store := &memoryResultStore{}
+		hook := &webhookHTTPClient{}
+		h := newRunHarness(t, "9.3.0", scriptedResponse{body: `{"stable": "9.3.1"}`})
+		h.svc.resultStore = store
+		h.svc.webhook = newTestWebhookNotifier(hook, "")
+		h.start()
+		require.ErrorIs(t, h.stop(), context.Canceled)
+		require.Len(t, hook.requests(), 1)
+		require.Equal(t, "9.3.1", store.status.WebhookVersion)
+
+		restarted := &webhookHTTPClient{}
+		h = newRunHarness(t, "9.3.0", scriptedResponse{body: `{"stable": "9.3.1"}`})
+		h.svc.resultStore = store
+		h.svc.webhook = newTestWebhookNotifier(restarted, "")
+		h.start()
+		require.ErrorIs(t, h.stop(), context.Canceled)
+		require.Empty(t, restarted.requests())
+	})
+
+	t.Run("is only set up with a URL", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		require.Nil(t, provideTestGrafanaService(cfg).webhook)
+
+		cfg.UpdateCheckerWebhookURL = "https://hooks.example.com/upgrade"
+		cfg.UpdateCheckerWebhookSecret = "s3cr3t"
+		webhook := provideTestGrafanaService(cfg).webhook
+		require.NotNil(t, webhook)
+		require.Equal(t, "https://hooks.example.com/upgrade", webhook.url)
+		require.Equal(t, "s3cr3t", webhook.secret)
+	})
+}
+
+func newTestWebhookNotifier(client httpClient, secret string) *webhookNotifier {
+	return &webhookNotifier{
+		url:        "https://hooks.example.com/upgrade",
+		secret:     secret,
+		retryDelay: time.Millisecond,
+		httpClient: client,
+		clock:      clock.New(),
+		log:        log.NewNopLogger(),
+	}
+}
+
+type webhookRequest struct {
+	url    string
+	header http.Header
+	body   []byte
+}
+
+// webhookHTTPClient records webhook calls, which are made in the background.
+// It answers with statuses in order, then with 200, and blocks until release is
+// closed or the request is cancelled if it's set, signalling started first.
+type webhookHTTPClient struct {
+	statuses []int
+	err      error
+	release  chan struct{}
+	started  chan struct{}
+
+	mutex     sync.Mutex
+	received  []webhookRequest
+	cancelled int
+}
+
+func (c *webhookHTTPClient) Get(url string) (*http.Response, error) {
+	return nil, errors.New("webhooks are posted")
+}
+
+func (c *webhookHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	if c.started != nil {
+		c.started <- struct{}{}
+	}
+	if c.release != nil {
+		select {
+		case <-c.release:
+		case <-req.Context().Done():
+			c.mutex.Lock()
+			defer c.mutex.Unlock()
+			c.cancelled++
+			return nil, req.Context().Err()
+		}
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.received = append(c.received, webhookRequest{url: req.URL.String(), header: req.Header.Clone(), body: body})
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	status := http.StatusOK
+	if len(c.statuses) > 0 {
+		status, c.statuses = c.statuses[0], c.statuses[1:]
+	}
+	return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(""))}, nil
+}
+
+func (c *webhookHTTPClient) requests() []webhookRequest {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return append([]webhookRequest(nil), c.received...)
+}
@@ -0,0 +1,471 @@
+package updatechecker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-version"
+)
+
+// latestJSON is the payload served by the update server.
+type latestJSON struct {
+	// SchemaVersion is the version of the schema of the payload, see
+	// latestSchemaVersion.
+	SchemaVersion int `json:"schemaVersion"`
+
+	Stable  string `json:"stable"`
+	Testing string `json:"testing"`
+	// Security is set when the latest release contains security fixes.
+	Security bool `json:"security"`
+	// Recommended is the suggested upgrade target, e.g. the latest LTS
+	// release, which may be older than Stable.
+	Recommended string `json:"recommended"`
+
+	// Releases and Next are set by paginated release indices, where a page
+	// lists some of the released versions and links to the next page.
+	Releases []string `json:"releases"`
+	Next     string   `json:"next"`
+
+	// ReleaseDates maps versions to the date they were released, either as
+	// RFC 3339 timestamps or as YYYY-MM-DD dates.
+	ReleaseDates map[string]string `json:"releaseDates"`
+
+	// UpdateAvailable is set by update servers that decide whether the
+	// requesting instance should update, see ComparisonServer.
+	UpdateAvailable *bool `json:"updateAvailable"`
+
+	// Builds describes the latest build of versions, which changes when a
+	// version is rebuilt, e.g. for a security fix, without a version bump.
+	Builds map[string]buildInfo `json:"builds"`
+
+	// GeneratedAt is when the update server last refreshed its data, as an
+	// RFC 3339 timestamp.
+	GeneratedAt string `json:"generatedAt"`
+
+	// Vulnerable lists known vulnerabilities and the versions they affect.
+	Vulnerable []vulnerability `json:"vulnerable"`
+
+	// Supported lists the version lines, e.g. "10.x", that still receive
+	// updates.
+	Supported []string `json:"supported"`
+
+	// ChangelogURLTemplate links to the changes between two versions, with
+	// {from} and {to} standing in for them.
+	ChangelogURLTemplate string `json:"changelogURLTemplate"`
+
+	// Approved lists the versions approved for deployment, see
+	// LatestApprovedVersion.
+	Approved []string `json:"approved"`
+
+	// EOL lists the versions or release lines, e.g. "8.x", that no longer
+	// receive updates.
+	EOL []string `json:"eol"`
+
+	// EOLDates maps release lines, e.g. "9.x" or "9.4.x", to when they reach
+	// their end of life, in the same formats as ReleaseDates.
+	EOLDates map[string]string `json:"eolDates"`
+
+	// RequiresMigration flags the versions whose upgrade runs database
+	// migrations.
+	RequiresMigration map[string]bool `json:"requiresMigration"`
+
+	// UpgradePath maps release lines, e.g. "8.x" or "8.5.x", to the versions
+	// to upgrade through, in order, before upgrading to the latest version.
+	UpgradePath map[string][]string `json:"upgradePath"`
+
+	// Platforms holds versions for distributions with staggered releases,
+	// keyed by "GOOS/GOARCH" or just "GOOS".
+	Platforms map[string]platformVersions `json:"platforms"`
+}
+
+// UnmarshalJSON accepts renamed keys under their new names as well as their
+// old ones, so that the update server can move to the new names without
+// breaking older clients. The new name wins when a payload has both.
+func (l *latestJSON) UnmarshalJSON(data []byte) error {
+	// plain has the same fields but not this method, avoiding recursion.
+	type plain latestJSON
+	var payload struct {
+		plain
+		LatestStable  *string `json:"latestStable"`
+		LatestTesting *string `json:"latestTesting"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return err
+	}
+
+	*l = latestJSON(payload.plain)
+	if payload.LatestStable != nil {
+		l.Stable = *payload.LatestStable
+	}
+	if payload.LatestTesting != nil {
+		l.Testing = *payload.LatestTesting
+	}
+	return nil
+}
+
+// releaseDate returns the release date of the given version, if the payload
+// has one.
+func (l *latestJSON) releaseDate(v string) (time.Time, bool, error) {
+	date, ok := l.ReleaseDates[v]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+
+	t, err := parseReleaseDate(date)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return t, true, nil
+}
+
+// endOfLife returns the end of life date of the release line of the given
+// version, preferring the date of its minor line over that of its major line.
+func (l *latestJSON) endOfLife(v *version.Version) (time.Time, bool, error) {
+	if v == nil {
+		return time.Time{}, false, nil
+	}
+
+	for _, line := range releaseLines(v) {
+		date, ok := l.EOLDates[line]
+		if !ok {
+			continue
+		}
+		t, err := parseReleaseDate(date)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		return t, true, nil
+	}
+	return time.Time{}, false, nil
+}
+
+// upgradeHops returns the versions to upgrade through from current before
+// upgrading to latest, preferring the path of the minor line of current over
+// that of its major line. Hops that aren't between the two are skipped.
+func (l *latestJSON) upgradeHops(current, latest *version.Version) []string {
+	if current == nil || latest == nil {
+		return nil
+	}
+
+	for _, line := range releaseLines(current) {
+		path, ok := l.UpgradePath[line]
+		if !ok {
+			continue
+		}
+		var hops []string
+		for _, hop := range path {
+			parsed, err := version.NewVersion(hop)
+			if err != nil || !current.LessThan(parsed) || !parsed.LessThan(latest) {
+				continue
+			}
+			hops = append(hops, hop)
+		}
+		return hops
+	}
+	return nil
+}
+
+// releaseLines returns the minor and major release lines of v, e.g. "9.4.x"
+// and "9.x".
+func releaseLines(v *version.Version) []string {
+	segments := v.Segments()
+	return []string{fmt.Sprintf("%d.%d.x", segments[0], segments[1]), fmt.Sprintf("%d.x", segments[0])}
+}
+
+// requiresMigration reports whether any version newer than current, up to and
+// including latest, is flagged as running database migrations.
+func (l *latestJSON) requiresMigration(current, latest *version.Version) bool {
+	if current == nil || latest == nil {
+		return false
+	}
+
+	for v, flagged := range l.RequiresMigration {
+		if !flagged {
+			continue
+		}
+		parsed, err := version.NewVersion(v)
+		if err != nil {
+			continue
+		}
+		if current.LessThan(parsed) && !latest.LessThan(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseReleaseDate(date string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, date); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", date)
+}
+
+type buildInfo struct {
+	Commit string `json:"commit"`
+	// Timestamp is when the build was made, as an RFC 3339 timestamp.
+	Timestamp string `json:"timestamp"`
+}
+
+type platformVersions struct {
+	Stable  string `json:"stable"`
+	Testing string `json:"testing"`
+}
+
+// applyPlatform overrides the stable and testing versions with the entry for
+// the given platform, preferring a "GOOS/GOARCH" entry over a "GOOS" one.
+// Versions the entry leaves empty keep their generic value.
+func (l *latestJSON) applyPlatform(goos, goarch string) {
+	entry, ok := l.Platforms[goos+"/"+goarch]
+	if !ok {
+		entry, ok = l.Platforms[goos]
+	}
+	if !ok {
+		return
+	}
+
+	if entry.Stable != "" {
+		l.Stable = entry.Stable
+	}
+	if entry.Testing != "" {
+		l.Testing = entry.Testing
+	}
+}
+
+// fillFromReleases derives missing stable and testing versions from the
+// newest listed releases.
+func (l *latestJSON) fillFromReleases() {
+	var stable, testing *version.Version
+	for _, r := range l.Releases {
+		v, err := version.NewVersion(r)
+		if err != nil {
+			continue
+		}
+
+		if testing == nil || testing.LessThan(v) {
+			testing = v
+		}
+		if v.Prerelease() == "" && (stable == nil || stable.LessThan(v)) {
+			stable = v
+		}
+	}
+
+	if l.Stable == "" && stable != nil {
+		l.Stable = stable.Original()
+	}
+	if l.Testing == "" && testing != nil {
+		l.Testing = testing.Original()
+	}
+}
+
+// validate checks that a parsed payload is usable before it's applied: the
+// stable version must be present, every version in it must be valid semver,
+// every release line must be one of releaseLines, every platform must be
+// "GOOS" or "GOOS/GOARCH" and every date must be parseable.
+func validate(latest latestJSON) error {
+	if latest.Stable == "" {
+		return errors.New("stable version is missing")
+	}
+	if err := validateVersion("stable", latest.Stable); err != nil {
+		return err
+	}
+	if err := validateVersion("testing", latest.Testing); err != nil {
+		return err
+	}
+	if err := validateVersion("recommended", latest.Recommended); err != nil {
+		return err
+	}
+	for _, r := range latest.Releases {
+		if err := validateVersion("release", r); err != nil {
+			return err
+		}
+	}
+	for v, date := range latest.ReleaseDates {
+		if err := validateVersion("release date", v); err != nil {
+			return err
+		}
+		if _, err := parseReleaseDate(date); err != nil {
+			return fmt.Errorf("invalid release date %q of version %q: %w", date, v, err)
+		}
+	}
+	for v, build := range latest.Builds {
+		if err := validateVersion("build", v); err != nil {
+			return err
+		}
+		if build.Timestamp == "" {
+			continue
+		}
+		if _, err := time.Parse(time.RFC3339, build.Timestamp); err != nil {
+			return fmt.Errorf("invalid build timestamp %q of version %q: %w", build.Timestamp, v, err)
+		}
+	}
+	for _, v := range latest.Vulnerable {
+		if v.CVE == "" {
+			return errors.New("vulnerability without an ID")
+		}
+		if _, err := version.NewConstraint(v.Versions); err != nil {
+			return fmt.Errorf("invalid affected versions %q of %s: %w", v.Versions, v.CVE, err)
+		}
+	}
+	if latest.GeneratedAt != "" {
+		if _, err := time.Parse(time.RFC3339, latest.GeneratedAt); err != nil {
+			return fmt.Errorf("invalid generatedAt %q: %w", latest.GeneratedAt, err)
+		}
+	}
+	for line, date := range latest.EOLDates {
+		if err := validateReleaseLine("end of life", line); err != nil {
+			return err
+		}
+		if _, err := parseReleaseDate(date); err != nil {
+			return fmt.Errorf("invalid end of life date %q of release line %q: %w", date, line, err)
+		}
+	}
+	for line, path := range latest.UpgradePath {
+		if err := validateReleaseLine("upgrade path", line); err != nil {
+			return err
+		}
+		for _, hop := range path {
+			if err := validateVersion("upgrade path", hop); err != nil {
+				return err
+			}
+		}
+	}
+	for _, line := range latest.Supported {
+		if err := validateReleaseLine("supported", line); err != nil {
+			return err
+		}
+	}
+	for _, v := range latest.Approved {
+		if err := validateVersion("approved", v); err != nil {
+			return err
+		}
+	}
+	for v := range latest.RequiresMigration {
+		if err := validateVersion("migration", v); err != nil {
+			return err
+		}
+	}
+	for platform, versions := range latest.Platforms {
+		goos, goarch, hasArch := strings.Cut(platform, "/")
+		if goos == "" || hasArch && (goarch == "" || strings.Contains(goarch, "/")) {
+			return fmt.Errorf("invalid platform %q", platform)
+		}
+		if err := validateVersion(platform+" stable", versions.Stable); err != nil {
+			return err
+		}
+		if err := validateVersion(platform+" testing", versions.Testing); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateReleaseLine checks a release line, e.g. "9.x" or "9.4.x", as
+// returned by releaseLines.
+func validateReleaseLine(field, line string) error {
+	segments := strings.Split(strings.TrimSuffix(line, ".x"), ".")
+	if !strings.HasSuffix(line, ".x") || len(segments) > 2 {
+		return fmt.Errorf("invalid %s release line %q", field, line)
+	}
+	for _, segment := range segments {
+		if _, err := strconv.ParseUint(segment, 10, 64); err != nil {
+			return fmt.Errorf("invalid %s release line %q", field, line)
+		}
+	}
+	return nil
+}
+
+// validateVersion checks an optional version field, empty values are valid.
+func validateVersion(field, value string) error {
+	if value == "" {
+		return nil
+	}
+	if _, err := version.NewSemver(value); err != nil {
+		return fmt.Errorf("invalid %s version %q: %w", field, value, err)
+	}
+	return nil
+}
+
+// errTruncatedPayload tells payloads that end prematurely, e.g. because the
+// connection dropped mid-response, apart from malformed ones.
+var errTruncatedPayload = errors.New("latest.json is truncated")
+
+// decodeLatestJSONFrom decodes an update server payload as it's read from r,
+// without reading the whole document into memory first. When keyPath is set
+// the version info is expected to be nested under that dot-separated path
+// (e.g. "products.grafana") instead of at the top level of the document, and
+// the values of other keys are skipped over.
+func decodeLatestJSONFrom(r io.Reader, keyPath string) (latestJSON, error) {
+	dec := json.NewDecoder(r)
+	if keyPath != "" {
+		for _, key := range strings.Split(keyPath, ".") {
+			if err := seekKey(dec, key); err != nil {
+				return latestJSON{}, truncatedPayloadError(dec, err)
+			}
+		}
+	}
+
+	var payload schemaPayload
+	if err := dec.Decode(&payload); err != nil {
+		return latestJSON{}, truncatedPayloadError(dec, err)
+	}
+	if keyPath == "" {
+		if _, err := dec.Token(); err != io.EOF {
+			return latestJSON{}, fmt.Errorf("unexpected data after latest.json at offset %d", dec.InputOffset())
+		}
+	}
+	return payload.latest, nil
+}
+
+// seekKey advances dec to the value of key in the object it's positioned at.
+func seekKey(dec *json.Decoder, key string) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != json.Delim('{') {
+		return fmt.Errorf("failed to unmarshal object containing %q: found %v", key, tok)
+	}
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if tok == key {
+			return nil
+		}
+		var skipped json.RawMessage
+		if err := dec.Decode(&skipped); err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("key %q not found in payload", key)
+}
+
+// truncatedPayloadError reports payloads that end before their JSON does as
+// truncated.
+func truncatedPayloadError(dec *json.Decoder, err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%w after %d bytes", errTruncatedPayload, dec.InputOffset())
+	}
+	return err
+}
+
+// schemaPayload decodes a payload of any known schema version, see
+// decodeLatestJSON.
+type schemaPayload struct {
+	latest latestJSON
+}
+
+func (p *schemaPayload) UnmarshalJSON(data []byte) error {
+	latest, err := decodeLatestJSON(data)
+	p.latest = latest
+	return err
+}
@@ -0,0 +1,78 @@
+package updatechecker
+
+import "encoding/json"
+
+// PayloadChanges describes what changed between two latest.json snapshots.
+type PayloadChanges struct {
+	// NewStable and NewTesting are set to the current version of a channel
+	// when it differs from the previous snapshot.
+	NewStable  string
+	NewTesting string
+	// NewlySecurity is set when the current snapshot flags a security
+	// release and the previous one didn't.
+	NewlySecurity bool
+	// NewEOL lists the EOL entries missing from the previous snapshot, in
+	// the order of the current one.
+	NewEOL []string
+}
+
+// IsEmpty reports whether nothing changed between the snapshots.
+func (d PayloadChanges) IsEmpty() bool {
+	return d.NewStable == "" && d.NewTesting == "" && !d.NewlySecurity && len(d.NewEOL) == 0
+}
+
+// diffLatest compares the previous and current payloads, e.g. to tell users
+// what changed since the last check.
+func diffLatest(prev, curr latestJSON) PayloadChanges {
+	var diff PayloadChanges
+	if curr.Stable != prev.Stable {
+		diff.NewStable = curr.Stable
+	}
+	if curr.Testing != prev.Testing {
+		diff.NewTesting = curr.Testing
+	}
+	diff.NewlySecurity = curr.Security && !prev.Security
+
+	known := make(map[string]struct{}, len(prev.EOL))
+	for _, v := range prev.EOL {
+		known[v] = struct{}{}
+	}
+	for _, v := range curr.EOL {
+		if _, ok := known[v]; !ok {
+			diff.NewEOL = append(diff.NewEOL, v)
+			known[v] = struct{}{}
+		}
+	}
+
+	return diff
+}
+
+// changesSince diffs latest against the payload of the previous successful
+// check. Nothing changed as far as the first check is concerned.
+func (s *GrafanaService) changesSince(latest latestJSON) PayloadChanges {
+	s.mutex.RLock()
+	encoded := s.lastParsedPayload
+	s.mutex.RUnlock()
+	if encoded == nil {
+		return PayloadChanges{}
+	}
+
+	var prev latestJSON
+	if err := json.Unmarshal(encoded, &prev); err != nil {
+		s.log.Warn("Failed to decode the previous latest.json", "error", err)
+		return PayloadChanges{}
+	}
+	return diffLatest(prev, latest)
+}
+
+// ChangesSinceLastCheck returns what changed in the update server payload
+// between the last two successful checks, e.g. for a "what changed since you
+// last checked" notice.
+func (s *GrafanaService) ChangesSinceLastCheck() PayloadChanges {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	changes := s.lastChanges
+	changes.NewEOL = append([]string(nil), changes.NewEOL...)
+	return changes
+}
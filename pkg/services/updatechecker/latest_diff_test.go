@@ -0,0 +1,89 @@
+package updatechecker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log/logtest"
+)
+
+func TestDiffLatest(t *testing.T) {
+	prev := latestJSON{
+		Stable:  "9.3.0",
+		Testing: "9.4.0-beta1",
+		EOL:     []string{"7.x"},
+	}
+
+	tests := []struct {
+		name string
+		curr latestJSON
+		diff PayloadChanges
+	}{
+		{
+			name: "unchanged",
+			curr: prev,
+			diff: PayloadChanges{},
+		},
+		{
+			name: "new stable",
+			curr: latestJSON{Stable: "9.3.1", Testing: "9.4.0-beta1", EOL: []string{"7.x"}},
+			diff: PayloadChanges{NewStable: "9.3.1"},
+		},
+		{
+			name: "new testing",
+			curr: latestJSON{Stable: "9.3.0", Testing: "9.4.0-beta2", EOL: []string{"7.x"}},
+			diff: PayloadChanges{NewTesting: "9.4.0-beta2"},
+		},
+		{
+			name: "newly flagged security",
+			curr: latestJSON{Stable: "9.3.0", Testing: "9.4.0-beta1", Security: true, EOL: []string{"7.x"}},
+			diff: PayloadChanges{NewlySecurity: true},
+		},
+		{
+			name: "new EOL entries",
+			curr: latestJSON{Stable: "9.3.0", Testing: "9.4.0-beta1", EOL: []string{"8.x", "7.x", "8.x", "9.0.x"}},
+			diff: PayloadChanges{NewEOL: []string{"8.x", "9.0.x"}},
+		},
+		{
+			name: "everything changed",
+			curr: latestJSON{Stable: "9.4.0", Testing: "9.5.0-beta1", Security: true, EOL: []string{"8.x"}},
+			diff: PayloadChanges{NewStable: "9.4.0", NewTesting: "9.5.0-beta1", NewlySecurity: true, NewEOL: []string{"8.x"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := diffLatest(prev, tt.curr)
+			require.Equal(t, tt.diff, diff)
+			require.Equal(t, tt.name == "unchanged", diff.IsEmpty())
+		})
+	}
+
+	t.Run("security already flagged", func(t *testing.T) {
+		secure := latestJSON{Stable: "9.3.0", Security: true}
+		require.True(t, diffLatest(secure, secure).IsEmpty())
+	})
+}
+
+func TestGrafanaUpdateChecker_ChangesSinceLastCheck(t *testing.T) {
+	client := &fakeHTTPClient{fakeResp: `{"stable": "9.3.0", "eol": ["7.x"]}`}
+	svc := newTestGrafanaService("9.3.0", client)
+	logger := &logtest.Fake{}
+	svc.log = logger
+
+	require.NoError(t, svc.CheckNow(context.Background()))
+	require.True(t, svc.ChangesSinceLastCheck().IsEmpty())
+	require.Zero(t, logger.InfoLogs.Calls)
+
+	client.fakeResp = `{"stable": "9.3.1", "security": true, "eol": ["7.x", "8.x"]}`
+	require.NoError(t, svc.CheckNow(context.Background()))
+	require.Equal(t, PayloadChanges{NewStable: "9.3.1", NewlySecurity: true, NewEOL: []string{"8.x"}}, svc.ChangesSinceLastCheck())
+	require.Equal(t, 1, logger.InfoLogs.Calls)
+	require.Equal(t, "Update server payload changed since the last check", logger.InfoLogs.Message)
+
+	require.NoError(t, svc.CheckNow(context.Background()))
+	require.True(t, svc.ChangesSinceLastCheck().IsEmpty())
+	require.Equal(t, 1, logger.InfoLogs.Calls)
+}
@@ -0,0 +1,97 @@
+package updatechecker
+
+import (
+	"encoding/json"
+)
+
+// latestSchemaVersion is the schema version of latestJSON. Payloads without a
+// schemaVersion are assumed to use it.
+const latestSchemaVersion = 1
+
+// schemaAdapters map payloads of newer, documented schema versions to
+// latestJSON, keeping older clients working as the schema evolves.
+var schemaAdapters = map[int]func(raw json.RawMessage) (latestJSON, error){
+	2: adaptLatestJSONv2,
+}
+
+// decodeLatestJSON decodes a payload of any known schema version. Payloads of
+// an unknown version are decoded as the current one on a best effort basis,
+// leaving SchemaVersion set so that the caller can warn about it.
+func decodeLatestJSON(raw json.RawMessage) (latestJSON, error) {
+	var schema struct {
+		SchemaVersion int `json:"schemaVersion"`
+	}
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		return latestJSON{}, err
+	}
+
+	if adapt, ok := schemaAdapters[schema.SchemaVersion]; ok {
+		latest, err := adapt(raw)
+		latest.SchemaVersion = schema.SchemaVersion
+		return latest, err
+	}
+
+	var latest latestJSON
+	err := json.Unmarshal(raw, &latest)
+	return latest, err
+}
+
+func isKnownSchemaVersion(v int) bool {
+	if v <= latestSchemaVersion {
+		return true
+	}
+	_, ok := schemaAdapters[v]
+	return ok
+}
+
+// latestJSONv2 groups the versions by channel, each channel carrying its
+// release date and whether it's a security release:
+//
+//	{
+//	  "schemaVersion": 2,
+//	  "channels": {
+//	    "stable": {"version": "10.0.1", "releasedAt": "2023-06-22", "security": true},
+//	    "testing": {"version": "10.1.0-beta1"}
+//	  },
+//	  "recommended": "9.5.5"
+//	}
+type latestJSONv2 struct {
+	Channels    map[string]channelV2 `json:"channels"`
+	Recommended string               `json:"recommended"`
+	EOL         []string             `json:"eol"`
+	GeneratedAt string               `json:"generatedAt"`
+}
+
+type channelV2 struct {
+	Version    string `json:"version"`
+	ReleasedAt string `json:"releasedAt"`
+	Security   bool   `json:"security"`
+}
+
+func adaptLatestJSONv2(raw json.RawMessage) (latestJSON, error) {
+	var v2 latestJSONv2
+	if err := json.Unmarshal(raw, &v2); err != nil {
+		return latestJSON{}, err
+	}
+
+	stable, testing := v2.Channels[channelStable], v2.Channels[channelTesting]
+	latest := latestJSON{
+		Stable:      stable.Version,
+		Testing:     testing.Version,
+		Security:    stable.Security,
+		Recommended: v2.Recommended,
+		EOL:         v2.EOL,
+		GeneratedAt: v2.GeneratedAt,
+	}
+	for _, c := range []channelV2{stable, testing} {
+		if c.Version == "" || c.ReleasedAt == "" {
+			continue
+		}
+		if latest.ReleaseDates == nil {
+			latest.ReleaseDates = map[string]string{}
+		}
+		latest.ReleaseDates[c.Version] = c.ReleasedAt
+	}
+
+	return latest, nil
+}
@@ -0,0 +1,76 @@
+package updatechecker
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log/logtest"
+)
+
+func TestDecodeLatestJSONFrom_schemaVersions(t *testing.T) {
+	t.Run("v1 payload", func(t *testing.T) {
+		latest, err := decodeLatestJSONFrom(strings.NewReader(`{"schemaVersion": 1, "stable": "9.3.0", "testing": "9.4.0-beta1"}`), "")
+		require.NoError(t, err)
+		require.Equal(t, latestJSON{SchemaVersion: 1, Stable: "9.3.0", Testing: "9.4.0-beta1"}, latest)
+	})
+
+	t.Run("v2 payload is adapted", func(t *testing.T) {
+		latest, err := decodeLatestJSONFrom(strings.NewReader(`{
+			"schemaVersion": 2,
+			"channels": {
+				"stable": {"version": "10.0.1", "releasedAt": "2023-06-22", "security": true},
+				"testing": {"version": "10.1.0-beta1"}
+			},
+			"recommended": "9.5.5",
+			"eol": ["8.x"],
+			"generatedAt": "2023-06-23T08:00:00Z"
+		}`), "")
+		require.NoError(t, err)
+		require.Equal(t, latestJSON{
+			SchemaVersion: 2,
+			Stable:        "10.0.1",
+			Testing:       "10.1.0-beta1",
+			Security:      true,
+			Recommended:   "9.5.5",
+			ReleaseDates:  map[string]string{"10.0.1": "2023-06-22"},
+			EOL:           []string{"8.x"},
+			GeneratedAt:   "2023-06-23T08:00:00Z",
+		}, latest)
+	})
+
+	t.Run("nested v2 payload is adapted", func(t *testing.T) {
+		latest, err := decodeLatestJSONFrom(strings.NewReader(`{"products": {"grafana": {"schemaVersion": 2, "channels": {"stable": {"version": "10.0.1"}}}}}`), "products.grafana")
+		require.NoError(t, err)
+		require.Equal(t, "10.0.1", latest.Stable)
+	})
+}
+
+func TestGrafanaUpdateChecker_schemaVersions(t *testing.T) {
+	t.Run("checks v2 payloads", func(t *testing.T) {
+		logger := &logtest.Fake{}
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"schemaVersion": 2, "channels": {"stable": {"version": "9.3.1", "security": true}}}`})
+		svc.log = logger
+
+		_, err := svc.checkForUpdates(context.Background())
+		require.NoError(t, err)
+		require.True(t, svc.UpdateAvailable())
+		require.Equal(t, "9.3.1", svc.LatestVersion())
+		require.Equal(t, UpdateSeverityCritical, svc.UpdateSeverity())
+		require.Zero(t, logger.WarnLogs.Calls)
+	})
+
+	t.Run("warns about unknown schema versions", func(t *testing.T) {
+		logger := &logtest.Fake{}
+		svc := newTestGrafanaService("9.3.0", &fakeHTTPClient{fakeResp: `{"schemaVersion": 99, "stable": "9.3.1"}`})
+		svc.log = logger
+
+		_, err := svc.checkForUpdates(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "9.3.1", svc.LatestVersion())
+		require.Equal(t, 1, logger.WarnLogs.Calls)
+		require.Equal(t, "Update server uses an unknown latest.json schema version, reading it as the current one", logger.WarnLogs.Message)
+	})
+}
@@ -0,0 +1,290 @@
+package updatechecker
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		latest latestJSON
+		err    string
+	}{
+		{
+			name:   "stable only",
+			latest: latestJSON{Stable: "9.3.0"},
+		},
+		{
+			name: "all fields set",
+			latest: latestJSON{
+				Stable:      "9.3.0",
+				Testing:     "9.4.0-beta1",
+				Recommended: "v9.2.8",
+				Releases:    []string{"9.3.0", "9.4.0-beta1"},
+			},
+		},
+		{
+			name:   "missing stable",
+			latest: latestJSON{Testing: "9.4.0-beta1"},
+			err:    "stable version is missing",
+		},
+		{
+			name:   "malformed stable",
+			latest: latestJSON{Stable: "latest"},
+			err:    `invalid stable version "latest"`,
+		},
+		{
+			name:   "stable with surrounding whitespace",
+			latest: latestJSON{Stable: " 9.3.0"},
+			err:    `invalid stable version " 9.3.0"`,
+		},
+		{
+			name:   "malformed testing",
+			latest: latestJSON{Stable: "9.3.0", Testing: "9.4.0-"},
+			err:    `invalid testing version "9.4.0-"`,
+		},
+		{
+			name:   "malformed recommended",
+			latest: latestJSON{Stable: "9.3.0", Recommended: "lts"},
+			err:    `invalid recommended version "lts"`,
+		},
+		{
+			name: "release dates",
+			latest: latestJSON{Stable: "9.3.0", ReleaseDates: map[string]string{
+				"9.3.0": "2022-11-30T10:00:00Z",
+				"9.2.0": "2022-10-11",
+			}},
+		},
+		{
+			name:   "unparseable release date",
+			latest: latestJSON{Stable: "9.3.0", ReleaseDates: map[string]string{"9.3.0": "30/11/2022"}},
+			err:    `invalid release date "30/11/2022" of version "9.3.0"`,
+		},
+		{
+			name:   "release date of malformed version",
+			latest: latestJSON{Stable: "9.3.0", ReleaseDates: map[string]string{"9.x": "2022-11-30"}},
+			err:    `invalid release date version "9.x"`,
+		},
+		{
+			name:   "vulnerability without an ID",
+			latest: latestJSON{Stable: "9.3.0", Vulnerable: []vulnerability{{Versions: "< 9.3.0"}}},
+			err:    "vulnerability without an ID",
+		},
+		{
+			name:   "malformed affected versions",
+			latest: latestJSON{Stable: "9.3.0", Vulnerable: []vulnerability{{CVE: "CVE-2023-1387", Versions: "before 9.3"}}},
+			err:    `invalid affected versions "before 9.3" of CVE-2023-1387`,
+		},
+		{
+			name:   "unparseable generatedAt",
+			latest: latestJSON{Stable: "9.3.0", GeneratedAt: "yesterday"},
+			err:    `invalid generatedAt "yesterday"`,
+		},
+		{
+			name:   "unparseable build timestamp",
+			latest: latestJSON{Stable: "9.3.0", Builds: map[string]buildInfo{"9.3.0": {Timestamp: "2023-03-13"}}},
+			err:    `invalid build timestamp "2023-03-13" of version "9.3.0"`,
+		},
+		{
+			name: "end of life dates",
+			latest: latestJSON{Stable: "9.3.0", EOLDates: map[string]string{
+				"9.x":   "2023-06-01",
+				"9.3.x": "2023-03-02T00:00:00Z",
+			}},
+		},
+		{
+			name:   "unparseable end of life date",
+			latest: latestJSON{Stable: "9.3.0", EOLDates: map[string]string{"9.x": "soon"}},
+			err:    `invalid end of life date "soon" of release line "9.x"`,
+		},
+		{
+			name:   "end of life date of a version",
+			latest: latestJSON{Stable: "9.3.0", EOLDates: map[string]string{"9.3.0": "2023-06-01"}},
+			err:    `invalid end of life release line "9.3.0"`,
+		},
+		{
+			name:   "end of life date of a malformed release line",
+			latest: latestJSON{Stable: "9.3.0", EOLDates: map[string]string{"v9.x": "2023-06-01"}},
+			err:    `invalid end of life release line "v9.x"`,
+		},
+		{
+			name: "upgrade paths",
+			latest: latestJSON{Stable: "9.3.0", UpgradePath: map[string][]string{
+				"8.x":   {"8.5.0", "9.0.0"},
+				"8.4.x": {"8.5.0"},
+			}},
+		},
+		{
+			name:   "upgrade path of a malformed release line",
+			latest: latestJSON{Stable: "9.3.0", UpgradePath: map[string][]string{"8": {"9.0.0"}}},
+			err:    `invalid upgrade path release line "8"`,
+		},
+		{
+			name:   "malformed upgrade path hop",
+			latest: latestJSON{Stable: "9.3.0", UpgradePath: map[string][]string{"8.x": {"9.x"}}},
+			err:    `invalid upgrade path version "9.x"`,
+		},
+		{
+			name:   "supported release lines",
+			latest: latestJSON{Stable: "9.3.0", Supported: []string{"9.x", "8.5.x"}},
+		},
+		{
+			name:   "malformed supported release line",
+			latest: latestJSON{Stable: "9.3.0", Supported: []string{"9.x", "latest"}},
+			err:    `invalid supported release line "latest"`,
+		},
+		{
+			name:   "approved versions",
+			latest: latestJSON{Stable: "9.3.0", Approved: []string{"9.2.8", "9.3.0"}},
+		},
+		{
+			name:   "malformed approved version",
+			latest: latestJSON{Stable: "9.3.0", Approved: []string{"9.2.x"}},
+			err:    `invalid approved version "9.2.x"`,
+		},
+		{
+			name:   "migrations",
+			latest: latestJSON{Stable: "9.3.0", RequiresMigration: map[string]bool{"9.3.0": true, "9.2.0": false}},
+		},
+		{
+			name:   "migration of a malformed version",
+			latest: latestJSON{Stable: "9.3.0", RequiresMigration: map[string]bool{"next": true}},
+			err:    `invalid migration version "next"`,
+		},
+		{
+			name: "platforms",
+			latest: latestJSON{Stable: "9.3.0", Platforms: map[string]platformVersions{
+				"windows/amd64": {Stable: "9.2.8"},
+				"darwin":        {Testing: "9.4.0-beta1"},
+			}},
+		},
+		{
+			name:   "malformed platform",
+			latest: latestJSON{Stable: "9.3.0", Platforms: map[string]platformVersions{"windows/": {Stable: "9.2.8"}}},
+			err:    `invalid platform "windows/"`,
+		},
+		{
+			name:   "malformed platform version",
+			latest: latestJSON{Stable: "9.3.0", Platforms: map[string]platformVersions{"windows": {Testing: "beta"}}},
+			err:    `invalid windows testing version "beta"`,
+		},
+		{
+			name:   "malformed release",
+			latest: latestJSON{Stable: "9.3.0", Releases: []string{"9.3.0", "9.x"}},
+			err:    `invalid release version "9.x"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validate(tt.latest)
+			if tt.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestLatestJSON_applyPlatform(t *testing.T) {
+	platforms := map[string]platformVersions{
+		"windows/amd64": {Stable: "9.2.8"},
+		"windows":       {Stable: "9.2.7", Testing: "9.3.0-beta1"},
+		"darwin":        {Testing: "9.4.0-beta2"},
+	}
+
+	tests := []struct {
+		name    string
+		goos    string
+		goarch  string
+		stable  string
+		testing string
+	}{
+		{
+			name:    "exact platform match",
+			goos:    "windows",
+			goarch:  "amd64",
+			stable:  "9.2.8",
+			testing: "9.4.0-beta1",
+		},
+		{
+			name:    "operating system match",
+			goos:    "windows",
+			goarch:  "arm64",
+			stable:  "9.2.7",
+			testing: "9.3.0-beta1",
+		},
+		{
+			name:    "empty platform versions keep the generic ones",
+			goos:    "darwin",
+			goarch:  "arm64",
+			stable:  "9.3.1",
+			testing: "9.4.0-beta2",
+		},
+		{
+			name:    "falls back to the generic versions",
+			goos:    "linux",
+			goarch:  "amd64",
+			stable:  "9.3.1",
+			testing: "9.4.0-beta1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			latest := latestJSON{Stable: "9.3.1", Testing: "9.4.0-beta1", Platforms: platforms}
+			latest.applyPlatform(tt.goos, tt.goarch)
+
+			require.Equal(t, tt.stable, latest.Stable)
+			require.Equal(t, tt.testing, latest.Testing)
+		})
+	}
+}
+
+func TestLatestJSON_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		stable  string
+		testing string
+	}{
+		{
+			name:    "old keys",
+			payload: `{"stable": "9.3.1", "testing": "9.4.0-beta1"}`,
+			stable:  "9.3.1",
+			testing: "9.4.0-beta1",
+		},
+		{
+			name:    "new keys",
+			payload: `{"latestStable": "9.3.1", "latestTesting": "9.4.0-beta1"}`,
+			stable:  "9.3.1",
+			testing: "9.4.0-beta1",
+		},
+		{
+			name:    "new keys take precedence",
+			payload: `{"stable": "9.3.0", "latestStable": "9.3.1", "testing": "9.4.0-beta1", "latestTesting": "9.4.0-beta2"}`,
+			stable:  "9.3.1",
+			testing: "9.4.0-beta2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var latest latestJSON
+			require.NoError(t, json.Unmarshal([]byte(tt.payload), &latest))
+			require.Equal(t, tt.stable, latest.Stable)
+			require.Equal(t, tt.testing, latest.Testing)
+		})
+	}
+
+	t.Run("other fields are still decoded", func(t *testing.T) {
+		latest, err := decodeLatestJSONFrom(strings.NewReader(`{"latestStable": "9.3.1", "security": true, "supported": ["9.x"]}`), "")
+		require.NoError(t, err)
+		require.Equal(t, latestJSON{Stable: "9.3.1", Security: true, Supported: []string{"9.x"}}, latest)
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -18,6 +19,7 @@ import (
 
 type PluginsService struct {
 	availableUpdates map[string]string
+	pluginUpdates    []PluginUpdateInfo
 
 	enabled        bool
 	grafanaVersion string
@@ -40,6 +42,7 @@ func ProvidePluginsService(cfg *setting.Cfg, pluginStore plugins.Store) *Plugins
 
 type httpClient interface {
 	Get(url string) (resp *http.Response, err error)
+	Do(req *http.Request) (resp *http.Response, err error)
 }
 
 func (s *PluginsService) IsDisabled() bool {
@@ -117,19 +120,46 @@ func (s *PluginsService) checkForUpdates(ctx context.Context) {
 	}
 
 	availableUpdates := map[string]string{}
+	var pluginUpdates []PluginUpdateInfo
 	for _, gcomP := range gcomPlugins {
 		if localP, exists := localPlugins[gcomP.Slug]; exists {
 			if canUpdate(localP.Info.Version, gcomP.Version) {
 				availableUpdates[localP.ID] = gcomP.Version
+				pluginUpdates = append(pluginUpdates, PluginUpdateInfo{ID: localP.ID, Installed: localP.Info.Version, Latest: gcomP.Version})
 			}
 		}
 	}
+	sort.Slice(pluginUpdates, func(i, j int) bool { return pluginUpdates[i].ID < pluginUpdates[j].ID })
 
-	if len(availableUpdates) > 0 {
-		s.mutex.Lock()
-		s.availableUpdates = availableUpdates
-		s.mutex.Unlock()
-	}
+	s.mutex.Lock()
+	s.availableUpdates = availableUpdates
+	s.pluginUpdates = pluginUpdates
+	s.mutex.Unlock()
+}
+
+// PluginUpdateInfo is an installed plugin with a newer version available.
+type PluginUpdateInfo struct {
+	ID        string `json:"id"`
+	Installed string `json:"installed"`
+	Latest    string `json:"latest"`
+}
+
+// PluginsWithUpdates returns the number of plugins with a newer version
+// available as of the last check, e.g. for a badge in the UI.
+func (s *PluginsService) PluginsWithUpdates() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return len(s.pluginUpdates)
+}
+
+// PluginUpdates returns the plugins with a newer version available as of the
+// last check, ordered by plugin ID.
+func (s *PluginsService) PluginUpdates() []PluginUpdateInfo {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return append([]PluginUpdateInfo(nil), s.pluginUpdates...)
 }
 
 func canUpdate(v1, v2 string) bool {
@@ -192,18 +192,91 @@ func TestPluginUpdateChecker_checkForUpdates(t *testing.T) {
 	})
 }
 
+func TestPluginUpdateChecker_PluginUpdates(t *testing.T) {
+	externalPlugin := func(id, version string) plugins.PluginDTO {
+		return plugins.PluginDTO{
+			JSONData: plugins.JSONData{
+				ID:   id,
+				Info: plugins.Info{Version: version},
+				Type: plugins.DataSource,
+			},
+			Class: plugins.External,
+		}
+	}
+
+	svc := PluginsService{
+		availableUpdates: map[string]string{},
+		pluginStore: plugins.FakePluginStore{
+			PluginList: []plugins.PluginDTO{
+				externalPlugin("test-ds", "0.9.0"),
+				externalPlugin("test-app", "1.2.0"),
+				externalPlugin("test-panel", "2.5.7"),
+				externalPlugin("test-unlisted", "0.1.0"),
+			},
+		},
+		httpClient: &fakeHTTPClient{
+			fakeResp: `[
+			  {"slug": "test-ds", "version": "1.0.12"},
+			  {"slug": "test-app", "version": "1.3.0"},
+			  {"slug": "test-panel", "version": "2.5.7"}
+			]`,
+		},
+		log: log.NewNopLogger(),
+	}
+	require.Zero(t, svc.PluginsWithUpdates())
+	require.Empty(t, svc.PluginUpdates())
+
+	svc.checkForUpdates(context.Background())
+
+	require.Equal(t, 2, svc.PluginsWithUpdates())
+	require.Equal(t, []PluginUpdateInfo{
+		{ID: "test-app", Installed: "1.2.0", Latest: "1.3.0"},
+		{ID: "test-ds", Installed: "0.9.0", Latest: "1.0.12"},
+	}, svc.PluginUpdates())
+
+	svc.httpClient = &fakeHTTPClient{
+		fakeResp: `[
+		  {"slug": "test-ds", "version": "0.9.0"},
+		  {"slug": "test-app", "version": "1.2.0"}
+		]`,
+	}
+	svc.checkForUpdates(context.Background())
+
+	require.Zero(t, svc.PluginsWithUpdates())
+	require.Empty(t, svc.PluginUpdates())
+	_, exists := svc.HasUpdate(context.Background(), "test-ds")
+	require.False(t, exists)
+}
+
 type fakeHTTPClient struct {
 	fakeResp string
 
-	requestURL string
+	requestURL    string
+	requestMethod string
+	requestHeader http.Header
+	requestBody   []byte
 }
 
 func (c *fakeHTTPClient) Get(url string) (*http.Response, error) {
 	c.requestURL = url
 
 	resp := &http.Response{
-		Body: io.NopCloser(strings.NewReader(c.fakeResp)),
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(c.fakeResp)),
 	}
 
 	return resp, nil
 }
+
+func (c *fakeHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	c.requestMethod = req.Method
+	c.requestHeader = req.Header
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		c.requestBody = body
+	}
+	return c.Get(req.URL.String())
+}
@@ -393,6 +393,60 @@ type Cfg struct {
 	ApplicationInsightsEndpointUrl      string
 	FeedbackLinksEnabled                bool
 
+	// Update checker
+	UpdateCheckerURLs                  []string
+	UpdateCheckerUnixSocket            string
+	UpdateCheckerIPFamily              string
+	UpdateCheckerTimeout               time.Duration
+	UpdateCheckerDialTimeout           time.Duration
+	UpdateCheckerTLSHandshakeTimeout   time.Duration
+	UpdateCheckerResponseHeaderTimeout time.Duration
+	UpdateCheckerPayloadKey            string
+	UpdateCheckerDigestSuffix          string
+	UpdateCheckerArtifactURL           string
+	UpdateCheckerChangelogURL          string
+	UpdateCheckerApprovedVersions      []string
+	UpdateCheckerMaxPages              int
+	UpdateCheckerClockSkewTolerance    time.Duration
+	UpdateCheckerStaleThreshold        time.Duration
+	UpdateCheckerMaxMajorDistance      int
+	UpdateCheckerMaxTestingLead        int
+	UpdateCheckerMaxBackoff            time.Duration
+	UpdateCheckerActiveHours           string
+	UpdateCheckerAlignChecks           bool
+	UpdateCheckerMaxChecks             int
+	UpdateCheckerHeartbeatInterval     time.Duration
+	UpdateCheckerSuppressInEphemeral   bool
+	UpdateCheckerIgnoreTesting         bool
+	UpdateCheckerChannelLadder         bool
+	UpdateCheckerComparison            string
+	UpdateCheckerVersionComponents     int
+	UpdateCheckerRebuildsAsUpdates     bool
+	UpdateCheckerMethod                string
+	UpdateCheckerTraceConnections      bool
+	UpdateCheckerSendInstanceID        bool
+	UpdateCheckerRegion                string
+
+	// DeploymentChannel tags the instance with its deployment environment,
+	// e.g. canary or prod, which selects the release channel it tracks.
+	DeploymentChannel string
+	// DeploymentMode is "managed" for instances whose upgrades are rolled out
+	// declaratively, e.g. pinned image tags, which suppresses the update banner.
+	DeploymentMode string
+	// UpdateNagInterval is how long an acknowledged update banner stays away
+	// before it's shown again.
+	UpdateNagInterval time.Duration
+	// ExposeUpdateStatusInHealth adds the cached update status to /api/health.
+	ExposeUpdateStatusInHealth bool
+	// NotifyAboutBetas makes stable builds also report newer testing releases,
+	// separately from stable updates.
+	NotifyAboutBetas bool
+
+	UpdateCheckerNotificationContactPoint string
+	UpdateCheckerNotificationOrgID        int64
+	UpdateCheckerWebhookURL               string
+	UpdateCheckerWebhookSecret            string
+
 	// Frontend analytics
 	GoogleAnalyticsID                   string
 	GoogleAnalytics4ID                  string
@@ -1052,6 +1106,8 @@ func (cfg *Cfg) Load(args CommandLineArgs) error {
 	cfg.ApplicationInsightsEndpointUrl = analytics.Key("application_insights_endpoint_url").String()
 	cfg.FeedbackLinksEnabled = analytics.Key("feedback_links_enabled").MustBool(true)
 
+	cfg.readUpdateCheckerSettings(iniFile)
+
 	if err := readAlertingSettings(iniFile); err != nil {
 		return err
 	}
@@ -0,0 +1,54 @@
+package setting
+
+import (
+	"time"
+
+	"gopkg.in/ini.v1"
+
+	"github.com/grafana/grafana/pkg/util"
+)
+
+func (cfg *Cfg) readUpdateCheckerSettings(iniFile *ini.File) {
+	updateChecker := iniFile.Section("update_checker")
+	cfg.UpdateCheckerURLs = util.SplitString(updateChecker.Key("urls").MustString(""))
+	cfg.UpdateCheckerUnixSocket = updateChecker.Key("unix_socket").MustString("")
+	cfg.UpdateCheckerIPFamily = updateChecker.Key("ip_family").MustString("tcp")
+	cfg.UpdateCheckerTimeout = updateChecker.Key("timeout").MustDuration(10 * time.Second)
+	cfg.UpdateCheckerDialTimeout = updateChecker.Key("dial_timeout").MustDuration(5 * time.Second)
+	cfg.UpdateCheckerTLSHandshakeTimeout = updateChecker.Key("tls_handshake_timeout").MustDuration(5 * time.Second)
+	cfg.UpdateCheckerResponseHeaderTimeout = updateChecker.Key("response_header_timeout").MustDuration(5 * time.Second)
+	cfg.UpdateCheckerPayloadKey = updateChecker.Key("payload_key").MustString("")
+	cfg.UpdateCheckerDigestSuffix = updateChecker.Key("digest_suffix").MustString("")
+	cfg.UpdateCheckerArtifactURL = updateChecker.Key("artifact_url").MustString("")
+	cfg.UpdateCheckerChangelogURL = updateChecker.Key("changelog_url").MustString("")
+	cfg.UpdateCheckerApprovedVersions = util.SplitString(updateChecker.Key("approved_versions").MustString(""))
+	cfg.UpdateCheckerMaxPages = updateChecker.Key("max_pages").MustInt(5)
+	cfg.UpdateCheckerClockSkewTolerance = updateChecker.Key("clock_skew_tolerance").MustDuration(5 * time.Minute)
+	cfg.UpdateCheckerStaleThreshold = updateChecker.Key("stale_threshold").MustDuration(24 * time.Hour)
+	cfg.UpdateCheckerMaxMajorDistance = updateChecker.Key("max_major_distance").MustInt(2)
+	cfg.UpdateCheckerMaxTestingLead = updateChecker.Key("max_testing_lead").MustInt(1)
+	cfg.UpdateCheckerMaxBackoff = updateChecker.Key("max_backoff").MustDuration(0)
+	cfg.UpdateCheckerActiveHours = updateChecker.Key("active_hours").MustString("")
+	cfg.UpdateCheckerAlignChecks = updateChecker.Key("align_checks").MustBool(false)
+	cfg.UpdateCheckerMaxChecks = updateChecker.Key("max_checks").MustInt(0)
+	cfg.UpdateCheckerHeartbeatInterval = updateChecker.Key("heartbeat_interval").MustDuration(0)
+	cfg.UpdateCheckerSuppressInEphemeral = updateChecker.Key("suppress_in_ephemeral").MustBool(false)
+	cfg.UpdateCheckerIgnoreTesting = updateChecker.Key("ignore_testing").MustBool(false)
+	cfg.UpdateCheckerChannelLadder = updateChecker.Key("channel_ladder").MustBool(false)
+	cfg.UpdateCheckerComparison = updateChecker.Key("comparison").MustString("full")
+	cfg.UpdateCheckerVersionComponents = updateChecker.Key("version_components").MustInt(0)
+	cfg.UpdateCheckerRebuildsAsUpdates = updateChecker.Key("rebuilds_as_updates").MustBool(false)
+	cfg.UpdateCheckerMethod = updateChecker.Key("method").MustString("GET")
+	cfg.UpdateCheckerTraceConnections = updateChecker.Key("trace_connections").MustBool(false)
+	cfg.UpdateCheckerSendInstanceID = updateChecker.Key("send_instance_id").MustBool(false)
+	cfg.UpdateCheckerRegion = updateChecker.Key("region").MustString("")
+	cfg.DeploymentChannel = updateChecker.Key("deployment_channel").MustString("")
+	cfg.DeploymentMode = updateChecker.Key("deployment_mode").MustString("")
+	cfg.UpdateNagInterval = updateChecker.Key("nag_interval").MustDuration(0)
+	cfg.ExposeUpdateStatusInHealth = updateChecker.Key("expose_in_health").MustBool(false)
+	cfg.NotifyAboutBetas = updateChecker.Key("notify_about_betas").MustBool(false)
+	cfg.UpdateCheckerNotificationContactPoint = updateChecker.Key("notification_contact_point").MustString("")
+	cfg.UpdateCheckerNotificationOrgID = updateChecker.Key("notification_org_id").MustInt64(1)
+	cfg.UpdateCheckerWebhookURL = updateChecker.Key("webhook_url").MustString("")
+	cfg.UpdateCheckerWebhookSecret = updateChecker.Key("webhook_secret").MustString("")
+}
@@ -0,0 +1,201 @@
+package setting
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+)
+
+func TestUpdateCheckerSettings(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		cfg := NewCfg()
+		cfg.readUpdateCheckerSettings(ini.Empty())
+
+		require.Empty(t, cfg.UpdateCheckerURLs)
+		require.Empty(t, cfg.UpdateCheckerUnixSocket)
+		require.Equal(t, "tcp", cfg.UpdateCheckerIPFamily)
+		require.Equal(t, 10*time.Second, cfg.UpdateCheckerTimeout)
+		require.Equal(t, 5*time.Second, cfg.UpdateCheckerDialTimeout)
+		require.Equal(t, 5*time.Second, cfg.UpdateCheckerTLSHandshakeTimeout)
+		require.Equal(t, 5*time.Second, cfg.UpdateCheckerResponseHeaderTimeout)
+		require.Empty(t, cfg.UpdateCheckerPayloadKey)
+		require.Empty(t, cfg.UpdateCheckerDigestSuffix)
+		require.Empty(t, cfg.UpdateCheckerArtifactURL)
+		require.Empty(t, cfg.UpdateCheckerChangelogURL)
+		require.Empty(t, cfg.UpdateCheckerApprovedVersions)
+		require.Equal(t, 5, cfg.UpdateCheckerMaxPages)
+		require.Equal(t, 5*time.Minute, cfg.UpdateCheckerClockSkewTolerance)
+		require.Equal(t, 24*time.Hour, cfg.UpdateCheckerStaleThreshold)
+		require.Equal(t, 2, cfg.UpdateCheckerMaxMajorDistance)
+		require.Equal(t, 1, cfg.UpdateCheckerMaxTestingLead)
+		require.Zero(t, cfg.UpdateCheckerMaxBackoff)
+		require.Empty(t, cfg.UpdateCheckerActiveHours)
+		require.False(t, cfg.UpdateCheckerAlignChecks)
+		require.Zero(t, cfg.UpdateCheckerMaxChecks)
+		require.Zero(t, cfg.UpdateCheckerHeartbeatInterval)
+		require.False(t, cfg.UpdateCheckerSuppressInEphemeral)
+		require.False(t, cfg.UpdateCheckerIgnoreTesting)
+		require.False(t, cfg.UpdateCheckerChannelLadder)
+		require.Equal(t, "full", cfg.UpdateCheckerComparison)
+		require.Zero(t, cfg.UpdateCheckerVersionComponents)
+		require.False(t, cfg.UpdateCheckerRebuildsAsUpdates)
+		require.Equal(t, "GET", cfg.UpdateCheckerMethod)
+		require.False(t, cfg.UpdateCheckerTraceConnections)
+		require.False(t, cfg.UpdateCheckerSendInstanceID)
+		require.Empty(t, cfg.UpdateCheckerRegion)
+		require.Empty(t, cfg.DeploymentChannel)
+		require.Empty(t, cfg.DeploymentMode)
+		require.Zero(t, cfg.UpdateNagInterval)
+		require.False(t, cfg.ExposeUpdateStatusInHealth)
+		require.False(t, cfg.NotifyAboutBetas)
+		require.Empty(t, cfg.UpdateCheckerNotificationContactPoint)
+		require.Equal(t, int64(1), cfg.UpdateCheckerNotificationOrgID)
+		require.Empty(t, cfg.UpdateCheckerWebhookURL)
+		require.Empty(t, cfg.UpdateCheckerWebhookSecret)
+	})
+
+	t.Run("defaults.ini agrees with the defaults", func(t *testing.T) {
+		f, err := ini.Load("../../conf/defaults.ini")
+		require.NoError(t, err)
+		fromFile := NewCfg()
+		fromFile.readUpdateCheckerSettings(f)
+		defaults := NewCfg()
+		defaults.readUpdateCheckerSettings(ini.Empty())
+
+		require.False(t, fromFile.UpdateCheckerSuppressInEphemeral)
+		gotFields, wantFields := reflect.ValueOf(fromFile).Elem(), reflect.ValueOf(defaults).Elem()
+		for i := 0; i < gotFields.NumField(); i++ {
+			if name := gotFields.Type().Field(i).Name; strings.HasPrefix(name, "UpdateChecker") {
+				require.Equal(t, wantFields.Field(i).Interface(), gotFields.Field(i).Interface(), name)
+			}
+		}
+	})
+
+	t.Run("overrides", func(t *testing.T) {
+		f := ini.Empty()
+		sec, err := f.NewSection("update_checker")
+		require.NoError(t, err)
+		_, err = sec.NewKey("urls", "https://mirror1.example.com/latest.json, https://mirror2.example.com/latest.json")
+		require.NoError(t, err)
+		_, err = sec.NewKey("unix_socket", "unix:///run/update-agent.sock")
+		require.NoError(t, err)
+		_, err = sec.NewKey("ip_family", "tcp4")
+		require.NoError(t, err)
+		_, err = sec.NewKey("timeout", "30s")
+		require.NoError(t, err)
+		_, err = sec.NewKey("dial_timeout", "1s")
+		require.NoError(t, err)
+		_, err = sec.NewKey("tls_handshake_timeout", "2s")
+		require.NoError(t, err)
+		_, err = sec.NewKey("response_header_timeout", "3s")
+		require.NoError(t, err)
+		_, err = sec.NewKey("payload_key", "products.grafana")
+		require.NoError(t, err)
+		_, err = sec.NewKey("digest_suffix", ".sha256")
+		require.NoError(t, err)
+		_, err = sec.NewKey("artifact_url", "https://mirror.example.com/grafana-{version}.linux-amd64.tar.gz")
+		require.NoError(t, err)
+		_, err = sec.NewKey("changelog_url", "https://github.com/grafana/grafana/compare/v{from}...v{to}")
+		require.NoError(t, err)
+		_, err = sec.NewKey("approved_versions", "9.3.6, 9.4.2")
+		require.NoError(t, err)
+		_, err = sec.NewKey("clock_skew_tolerance", "1m")
+		require.NoError(t, err)
+		_, err = sec.NewKey("stale_threshold", "6h")
+		require.NoError(t, err)
+		_, err = sec.NewKey("max_major_distance", "0")
+		require.NoError(t, err)
+		_, err = sec.NewKey("max_testing_lead", "3")
+		require.NoError(t, err)
+		_, err = sec.NewKey("max_backoff", "1h")
+		require.NoError(t, err)
+		_, err = sec.NewKey("active_hours", "22:00-06:00 Europe/Berlin")
+		require.NoError(t, err)
+		_, err = sec.NewKey("align_checks", "true")
+		require.NoError(t, err)
+		_, err = sec.NewKey("max_checks", "3")
+		require.NoError(t, err)
+		_, err = sec.NewKey("heartbeat_interval", "1h")
+		require.NoError(t, err)
+		_, err = sec.NewKey("suppress_in_ephemeral", "true")
+		require.NoError(t, err)
+		_, err = sec.NewKey("ignore_testing", "true")
+		require.NoError(t, err)
+		_, err = sec.NewKey("channel_ladder", "true")
+		require.NoError(t, err)
+		_, err = sec.NewKey("comparison", "minor")
+		require.NoError(t, err)
+		_, err = sec.NewKey("version_components", "3")
+		require.NoError(t, err)
+		_, err = sec.NewKey("rebuilds_as_updates", "true")
+		require.NoError(t, err)
+		_, err = sec.NewKey("method", "POST")
+		require.NoError(t, err)
+		_, err = sec.NewKey("trace_connections", "true")
+		require.NoError(t, err)
+		_, err = sec.NewKey("send_instance_id", "true")
+		require.NoError(t, err)
+		_, err = sec.NewKey("region", "eu-west-1")
+		require.NoError(t, err)
+		_, err = sec.NewKey("deployment_channel", "canary")
+		require.NoError(t, err)
+		_, err = sec.NewKey("deployment_mode", "managed")
+		require.NoError(t, err)
+		_, err = sec.NewKey("nag_interval", "168h")
+		require.NoError(t, err)
+		_, err = sec.NewKey("expose_in_health", "true")
+		require.NoError(t, err)
+		_, err = sec.NewKey("notify_about_betas", "true")
+		require.NoError(t, err)
+		_, err = sec.NewKey("webhook_url", "https://ci.example.com/hooks/grafana-upgrade")
+		require.NoError(t, err)
+		_, err = sec.NewKey("webhook_secret", "s3cr3t")
+		require.NoError(t, err)
+
+		cfg := NewCfg()
+		cfg.readUpdateCheckerSettings(f)
+
+		require.Equal(t, []string{"https://mirror1.example.com/latest.json", "https://mirror2.example.com/latest.json"}, cfg.UpdateCheckerURLs)
+		require.Equal(t, "unix:///run/update-agent.sock", cfg.UpdateCheckerUnixSocket)
+		require.Equal(t, "tcp4", cfg.UpdateCheckerIPFamily)
+		require.Equal(t, 30*time.Second, cfg.UpdateCheckerTimeout)
+		require.Equal(t, 1*time.Second, cfg.UpdateCheckerDialTimeout)
+		require.Equal(t, 2*time.Second, cfg.UpdateCheckerTLSHandshakeTimeout)
+		require.Equal(t, 3*time.Second, cfg.UpdateCheckerResponseHeaderTimeout)
+		require.Equal(t, "products.grafana", cfg.UpdateCheckerPayloadKey)
+		require.Equal(t, ".sha256", cfg.UpdateCheckerDigestSuffix)
+		require.Equal(t, "https://mirror.example.com/grafana-{version}.linux-amd64.tar.gz", cfg.UpdateCheckerArtifactURL)
+		require.Equal(t, "https://github.com/grafana/grafana/compare/v{from}...v{to}", cfg.UpdateCheckerChangelogURL)
+		require.Equal(t, []string{"9.3.6", "9.4.2"}, cfg.UpdateCheckerApprovedVersions)
+		require.Equal(t, time.Minute, cfg.UpdateCheckerClockSkewTolerance)
+		require.Equal(t, 6*time.Hour, cfg.UpdateCheckerStaleThreshold)
+		require.Zero(t, cfg.UpdateCheckerMaxMajorDistance)
+		require.Equal(t, 3, cfg.UpdateCheckerMaxTestingLead)
+		require.Equal(t, time.Hour, cfg.UpdateCheckerMaxBackoff)
+		require.Equal(t, "22:00-06:00 Europe/Berlin", cfg.UpdateCheckerActiveHours)
+		require.True(t, cfg.UpdateCheckerAlignChecks)
+		require.Equal(t, 3, cfg.UpdateCheckerMaxChecks)
+		require.Equal(t, time.Hour, cfg.UpdateCheckerHeartbeatInterval)
+		require.True(t, cfg.UpdateCheckerSuppressInEphemeral)
+		require.True(t, cfg.UpdateCheckerIgnoreTesting)
+		require.True(t, cfg.UpdateCheckerChannelLadder)
+		require.Equal(t, "minor", cfg.UpdateCheckerComparison)
+		require.Equal(t, 3, cfg.UpdateCheckerVersionComponents)
+		require.True(t, cfg.UpdateCheckerRebuildsAsUpdates)
+		require.Equal(t, "POST", cfg.UpdateCheckerMethod)
+		require.True(t, cfg.UpdateCheckerTraceConnections)
+		require.True(t, cfg.UpdateCheckerSendInstanceID)
+		require.Equal(t, "eu-west-1", cfg.UpdateCheckerRegion)
+		require.Equal(t, "canary", cfg.DeploymentChannel)
+		require.Equal(t, "managed", cfg.DeploymentMode)
+		require.Equal(t, 7*24*time.Hour, cfg.UpdateNagInterval)
+		require.True(t, cfg.ExposeUpdateStatusInHealth)
+		require.True(t, cfg.NotifyAboutBetas)
+		require.Equal(t, "https://ci.example.com/hooks/grafana-upgrade", cfg.UpdateCheckerWebhookURL)
+		require.Equal(t, "s3cr3t", cfg.UpdateCheckerWebhookSecret)
+	})
+}